@@ -0,0 +1,216 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
+)
+
+// ABI is a parsed Solidity JSON ABI.
+type ABI struct {
+	Constructor Method
+	Methods     map[string]Method
+	Events      map[string]Event
+}
+
+// JSON parses a JSON ABI definition.
+func JSON(reader io.Reader) (ABI, error) {
+	var abi ABI
+	if err := json.NewDecoder(reader).Decode(&abi); err != nil {
+		return ABI{}, err
+	}
+	return abi, nil
+}
+
+// Pack encodes a call of the named method with its selector, or the
+// constructor arguments if name is empty.
+func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
+	if name == "" {
+		return abi.Constructor.Inputs.Pack(args...)
+	}
+	method, ok := abi.Methods[name]
+	if !ok {
+		return nil, fmt.Errorf("abi: method %q not found", name)
+	}
+	arguments, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(method.ID(), arguments...), nil
+}
+
+// Unpack decodes the return values of the named method, or the data of the
+// named event.
+func (abi ABI) Unpack(name string, data []byte) ([]interface{}, error) {
+	args, err := abi.getArguments(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return args.Unpack(data)
+}
+
+// UnpackIntoInterface decodes like Unpack and stores the values into v.
+func (abi ABI) UnpackIntoInterface(v interface{}, name string, data []byte) error {
+	args, err := abi.getArguments(name, data)
+	if err != nil {
+		return err
+	}
+	values, err := args.Unpack(data)
+	if err != nil {
+		return err
+	}
+	return args.Copy(v, values)
+}
+
+// UnpackIntoMap decodes like Unpack and stores the values into v by name.
+func (abi ABI) UnpackIntoMap(v map[string]interface{}, name string, data []byte) error {
+	args, err := abi.getArguments(name, data)
+	if err != nil {
+		return err
+	}
+	return args.UnpackIntoMap(v, data)
+}
+
+func (abi ABI) getArguments(name string, data []byte) (Arguments, error) {
+	if event, ok := abi.Events[name]; ok {
+		return event.Inputs, nil
+	}
+	method, ok := abi.Methods[name]
+	if !ok {
+		return nil, fmt.Errorf("abi: could not locate named method or event: %s", name)
+	}
+	if len(data)%32 != 0 {
+		return nil, fmt.Errorf("abi: improperly formatted output of %s: %d bytes", name, len(data))
+	}
+	return method.Outputs, nil
+}
+
+// UnpackLog decodes an event log into out, filling the indexed fields from
+// the topics and the others from the data.
+func (abi ABI) UnpackLog(out interface{}, event string, log types.Log) error {
+	topics, err := abi.eventTopics(event, log)
+	if err != nil {
+		return err
+	}
+	if len(log.Data) > 0 {
+		if err := abi.UnpackIntoInterface(out, event, log.Data); err != nil {
+			return err
+		}
+	}
+	return ParseTopics(out, abi.Events[event].Inputs.Indexed(), topics)
+}
+
+// UnpackLogIntoMap decodes an event log into out by argument name.
+func (abi ABI) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
+	topics, err := abi.eventTopics(event, log)
+	if err != nil {
+		return err
+	}
+	if len(log.Data) > 0 {
+		if err := abi.UnpackIntoMap(out, event, log.Data); err != nil {
+			return err
+		}
+	}
+	return ParseTopicsIntoMap(out, abi.Events[event].Inputs.Indexed(), topics)
+}
+
+// eventTopics returns the topics of log holding indexed arguments of event.
+func (abi ABI) eventTopics(event string, log types.Log) ([]common.Hash, error) {
+	ev, ok := abi.Events[event]
+	if !ok {
+		return nil, fmt.Errorf("abi: event %q not found", event)
+	}
+	if ev.Anonymous {
+		return log.Topics, nil
+	}
+	if len(log.Topics) == 0 || log.Topics[0] != ev.ID() {
+		return nil, ErrEventSignatureMismatch
+	}
+	return log.Topics[1:], nil
+}
+
+// MethodById looks up the method whose selector starts sigdata.
+func (abi *ABI) MethodById(sigdata []byte) (*Method, error) {
+	if len(sigdata) < 4 {
+		return nil, fmt.Errorf("abi: data too short (%d bytes) for abi method lookup", len(sigdata))
+	}
+	for _, method := range abi.Methods {
+		if bytes.Equal(method.ID(), sigdata[:4]) {
+			return &method, nil
+		}
+	}
+	return nil, fmt.Errorf("abi: no method with id: %#x", sigdata[:4])
+}
+
+// EventByID looks up the event whose ID is the given topic.
+func (abi *ABI) EventByID(topic common.Hash) (*Event, error) {
+	for _, event := range abi.Events {
+		if event.ID() == topic {
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("abi: no event with id: %s", topic.Hex())
+}
+
+func (abi *ABI) UnmarshalJSON(data []byte) error {
+	var fields []struct {
+		Type            string
+		Name            string
+		Inputs          []Argument
+		Outputs         []Argument
+		Constant        bool
+		Payable         bool
+		StateMutability string
+		Anonymous       bool
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	abi.Methods = make(map[string]Method)
+	abi.Events = make(map[string]Event)
+	for _, field := range fields {
+		var (
+			isConst   = field.Constant || field.StateMutability == "view" || field.StateMutability == "pure"
+			isPayable = field.Payable || field.StateMutability == "payable"
+		)
+		switch field.Type {
+		case "constructor":
+			abi.Constructor = Method{Inputs: field.Inputs, Payable: isPayable}
+
+		case "function", "":
+			if field.Name == "" {
+				return errors.New("abi: function without a name")
+			}
+			name := ResolveNameConflict(field.Name, func(s string) bool { _, ok := abi.Methods[s]; return ok })
+			abi.Methods[name] = Method{
+				Name:    name,
+				RawName: field.Name,
+				Const:   isConst,
+				Payable: isPayable,
+				Inputs:  field.Inputs,
+				Outputs: field.Outputs,
+			}
+
+		case "event":
+			name := ResolveNameConflict(field.Name, func(s string) bool { _, ok := abi.Events[s]; return ok })
+			abi.Events[name] = Event{
+				Name:      name,
+				RawName:   field.Name,
+				Anonymous: field.Anonymous,
+				Inputs:    field.Inputs,
+			}
+
+		case "fallback", "receive":
+			// Neither can be called by name, nothing to record.
+
+		default:
+			return fmt.Errorf("abi: could not recognize type %v of field %v", field.Type, field.Name)
+		}
+	}
+	return nil
+}
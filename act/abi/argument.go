@@ -0,0 +1,198 @@
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Argument is a named input or output of a method or event.
+type Argument struct {
+	Name    string
+	Type    Type
+	Indexed bool
+}
+
+type Arguments []Argument
+
+// ArgumentMarshaling is the JSON form of an argument.
+type ArgumentMarshaling struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	InternalType string               `json:"internalType,omitempty"`
+	Components   []ArgumentMarshaling `json:"components,omitempty"`
+	Indexed      bool                 `json:"indexed,omitempty"`
+}
+
+func (argument *Argument) UnmarshalJSON(data []byte) error {
+	var arg ArgumentMarshaling
+	if err := json.Unmarshal(data, &arg); err != nil {
+		return fmt.Errorf("abi: failed to unmarshal argument: %v", err)
+	}
+	typ, err := NewType(arg.Type, arg.InternalType, arg.Components)
+	if err != nil {
+		return err
+	}
+	argument.Name = arg.Name
+	argument.Type = typ
+	argument.Indexed = arg.Indexed
+	return nil
+}
+
+// NonIndexed returns the arguments that are not stored in log topics.
+func (arguments Arguments) NonIndexed() Arguments {
+	var ret []Argument
+	for _, arg := range arguments {
+		if !arg.Indexed {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+// Indexed returns the arguments stored in log topics.
+func (arguments Arguments) Indexed() Arguments {
+	var ret []Argument
+	for _, arg := range arguments {
+		if arg.Indexed {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+func (arguments Arguments) isTuple() bool {
+	return len(arguments) > 1
+}
+
+// Unpack decodes the non-indexed arguments from data.
+func (arguments Arguments) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) == 0 {
+		if len(arguments.NonIndexed()) != 0 {
+			return nil, errors.New("abi: attempting to unmarshal an empty string while arguments are expected")
+		}
+		return make([]interface{}, 0), nil
+	}
+	return arguments.UnpackValues(data)
+}
+
+// UnpackIntoMap decodes the non-indexed arguments from data into v, keyed by
+// argument name.
+func (arguments Arguments) UnpackIntoMap(v map[string]interface{}, data []byte) error {
+	values, err := arguments.Unpack(data)
+	if err != nil {
+		return err
+	}
+	for i, arg := range arguments.NonIndexed() {
+		v[arg.Name] = values[i]
+	}
+	return nil
+}
+
+// UnpackValues decodes the non-indexed arguments from data in order.
+func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
+	nonIndexed := arguments.NonIndexed()
+	values := make([]interface{}, 0, len(nonIndexed))
+	offset := 0
+	for _, arg := range nonIndexed {
+		value, err := toGoType(offset, arg.Type, data)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(arg.Type)
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Copy stores the values returned by Unpack into v, which must be a pointer
+// to a struct, a slice or array, or a single value for one argument.
+func (arguments Arguments) Copy(v interface{}, values []interface{}) error {
+	if reflect.Ptr != reflect.ValueOf(v).Kind() {
+		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
+	}
+	if len(values) == 0 {
+		if len(arguments.NonIndexed()) != 0 {
+			return errors.New("abi: attempting to copy no values while arguments are expected")
+		}
+		return nil
+	}
+	if arguments.isTuple() {
+		return arguments.copyTuple(v, values)
+	}
+	return arguments.copyAtomic(v, values[0])
+}
+
+func (arguments Arguments) copyAtomic(v interface{}, value interface{}) error {
+	dst := reflect.ValueOf(v).Elem()
+	src := reflect.ValueOf(value)
+	if dst.Kind() == reflect.Struct && src.Kind() != reflect.Struct {
+		return set(dst.Field(0), src)
+	}
+	return set(dst, src)
+}
+
+func (arguments Arguments) copyTuple(v interface{}, values []interface{}) error {
+	value := reflect.ValueOf(v).Elem()
+	nonIndexed := arguments.NonIndexed()
+
+	switch value.Kind() {
+	case reflect.Struct:
+		names := make([]string, len(nonIndexed))
+		for i, arg := range nonIndexed {
+			names[i] = arg.Name
+		}
+		abi2struct, err := mapArgNamesToStructFields(names, value)
+		if err != nil {
+			return err
+		}
+		for i, arg := range nonIndexed {
+			field := value.FieldByName(abi2struct[arg.Name])
+			if !field.IsValid() {
+				return fmt.Errorf("abi: field %s can't be found in the given value", arg.Name)
+			}
+			if err := set(field, reflect.ValueOf(values[i])); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Len() < len(values) {
+			return fmt.Errorf("abi: insufficient number of elements in the list/array for unpack, want %d, got %d", len(values), value.Len())
+		}
+		for i := range values {
+			if err := set(value.Index(i), reflect.ValueOf(values[i])); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("abi: cannot unmarshal tuple into %v", value.Type())
+	}
+	return nil
+}
+
+// Pack encodes args as the arguments, in order.
+func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
+	if len(args) != len(arguments) {
+		return nil, fmt.Errorf("abi: argument count mismatch: got %d for %d", len(args), len(arguments))
+	}
+	headSize := 0
+	for _, arg := range arguments {
+		headSize += getTypeSize(arg.Type)
+	}
+	var head, tail []byte
+	for i, a := range args {
+		typ := arguments[i].Type
+		packed, err := typ.pack(reflect.ValueOf(a))
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(typ) {
+			head = append(head, packNum(reflect.ValueOf(headSize+len(tail)))...)
+			tail = append(tail, packed...)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
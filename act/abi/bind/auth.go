@@ -0,0 +1,98 @@
+package bind
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/act"
+	"github.com/epvchain/go-epvchain/act/keystore"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+)
+
+var errNotAuthorized = errors.New("not authorized to sign this account")
+
+// NewTransactor is a utility method to easily create a transaction signer from
+// an encrypted json key stream and the associated passphrase.
+func NewTransactor(keyin io.Reader, passphrase string) (*TransactOpts, error) {
+	key, err := decryptKey(keyin, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyedTransactor(key.PrivateKey), nil
+}
+
+// NewKeyedTransactor is a utility method to easily create a transaction signer
+// from a single private key. It only signs legacy transactions.
+func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, errNotAuthorized
+			}
+			return types.SignTx(tx, types.HomesteadSigner{}, key)
+		},
+	}
+}
+
+// NewTransactorWithChainID is like NewTransactor, but signs replay protected
+// and dynamic fee transactions for the given chain.
+func NewTransactorWithChainID(keyin io.Reader, passphrase string, chainID *big.Int) (*TransactOpts, error) {
+	key, err := decryptKey(keyin, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyedTransactorWithChainID(key.PrivateKey, chainID)
+}
+
+// NewKeyedTransactorWithChainID is like NewKeyedTransactor, but signs replay
+// protected and dynamic fee transactions for the given chain.
+func NewKeyedTransactorWithChainID(key *ecdsa.PrivateKey, chainID *big.Int) (*TransactOpts, error) {
+	if chainID == nil {
+		return nil, errors.New("no chain id specified")
+	}
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.NewDynamicFeeSigner(chainID)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, errNotAuthorized
+			}
+			return types.SignTx(tx, signer, key)
+		},
+		ChainID: chainID,
+	}, nil
+}
+
+// NewKeyStoreTransactorWithChainID creates a transaction signer backed by an
+// unlocked account of the keystore.
+func NewKeyStoreTransactorWithChainID(ks *keystore.KeyStore, account accounts.Account, chainID *big.Int) (*TransactOpts, error) {
+	if chainID == nil {
+		return nil, errors.New("no chain id specified")
+	}
+	return &TransactOpts{
+		From: account.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account.Address {
+				return nil, errNotAuthorized
+			}
+			return ks.SignTx(account, tx, chainID)
+		},
+		ChainID: chainID,
+	}, nil
+}
+
+func decryptKey(keyin io.Reader, passphrase string) (*keystore.Key, error) {
+	json, err := ioutil.ReadAll(keyin)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.DecryptKey(json, passphrase)
+}
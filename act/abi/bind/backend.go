@@ -0,0 +1,71 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
+)
+
+var (
+	// ErrNoCode is returned by call and transact operations when the contract
+	// has no code at the requested address.
+	ErrNoCode = errors.New("no contract code at given address")
+
+	// ErrNoPendingState is returned by pending calls when the backend does not
+	// support the pending state.
+	ErrNoPendingState = errors.New("backend does not support pending state")
+
+	// ErrNoCodeAfterDeploy is returned by WaitDeployed if the contract creation
+	// left no code behind.
+	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+)
+
+// ContractCaller defines the methods needed to call contract methods in a
+// read-only manner.
+type ContractCaller interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call epvchain.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// PendingContractCaller defines the methods needed to call contract methods
+// against the pending state.
+type PendingContractCaller interface {
+	PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error)
+	PendingCallContract(ctx context.Context, call epvchain.CallMsg) ([]byte, error)
+}
+
+// ContractTransactor defines the methods needed to build, sign and send
+// contract transactions.
+type ContractTransactor interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call epvchain.CallMsg) (gas uint64, err error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// ContractFilterer defines the methods needed to retrieve and watch contract
+// events.
+type ContractFilterer interface {
+	FilterLogs(ctx context.Context, query epvchain.FilterQuery) ([]types.Log, error)
+	SubscribeFilterLogs(ctx context.Context, query epvchain.FilterQuery, ch chan<- types.Log) (epvchain.Subscription, error)
+}
+
+// DeployBackend defines the methods needed to wait for transactions and
+// deployments.
+type DeployBackend interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// ContractBackend defines everything needed to work with contracts.
+type ContractBackend interface {
+	ContractCaller
+	ContractTransactor
+	ContractFilterer
+}
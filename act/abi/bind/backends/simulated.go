@@ -0,0 +1,566 @@
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/epvchain/go-epvchain"
+	"github.com/epvchain/go-epvchain/act"
+	"github.com/epvchain/go-epvchain/act/abi/bind"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/math"
+	"github.com/epvchain/go-epvchain/agreement/epvdpos"
+	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/kernel/bloombits"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/kernel/vm"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/epv/filters"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/notice"
+	"github.com/epvchain/go-epvchain/content"
+	"github.com/epvchain/go-epvchain/remote"
+)
+
+// This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
+var _ bind.ContractBackend = (*SimulatedBackend)(nil)
+
+var (
+	errBlockNumberUnsupported = errors.New("simulated backend cannot access blocks other than the latest block")
+	errBlockDoesNotExist      = errors.New("block does not exist in blockchain")
+	errGasEstimationFailed    = errors.New("gas required exceeds allowance or always failing transaction")
+	errNegativeTimeAdjustment = errors.New("cannot adjust time backwards")
+	errPendingBlockDirty      = errors.New("pending block contains transactions")
+)
+
+const (
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
+)
+
+// SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
+// the background. Blocks are sealed by a single DPoS signer with a zero block
+// period, so every Commit produces a block immediately. Its main purpose is to
+// allow easily testing contract bindings.
+type SimulatedBackend struct {
+	database   epvdb.Database   // In memory database to store our testing data
+	blockchain *core.BlockChain // Chain to handle the consensus
+	engine     *epvdpos.DPos    // Consensus engine sealing the simulated blocks
+
+	signer    common.Address    // Sole authorized signer of the chain
+	signerKey *ecdsa.PrivateKey // Key of the signer to seal blocks with
+
+	mu           sync.Mutex
+	pendingBlock *types.Block   // Currently pending block that will be imported on request
+	pendingState *state.StateDB // Currently pending state that will be the active on on request
+	pendingShift int64          // Milliseconds the pending block's timestamp is moved forward by
+
+	events *filters.EventSystem // Event system for filtering log events live
+
+	config *params.ChainConfig
+}
+
+// NewSimulatedBackend creates a new binding backend using a simulated blockchain
+// for testing purposes, with the given accounts allocated in the genesis block.
+func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	database, _ := epvdb.NewMemDatabase()
+	genesis := core.DeveloperGenesisBlock(0, signer)
+	genesis.GasLimit = gasLimit
+	genesis.Alloc = alloc
+	genesis.MustCommit(database)
+
+	engine := epvdpos.New(genesis.Config.DPos, database)
+	engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, engine, vm.Config{})
+
+	backend := &SimulatedBackend{
+		database:   database,
+		blockchain: blockchain,
+		engine:     engine,
+		signer:     signer,
+		signerKey:  key,
+		config:     genesis.Config,
+		events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
+	}
+	backend.rollback(blockchain.CurrentBlock())
+	return backend
+}
+
+// Close terminates the underlying blockchain's update loop.
+func (b *SimulatedBackend) Close() error {
+	b.blockchain.Stop()
+	return nil
+}
+
+// Commit seals the pending transactions into a new block and imports it into
+// the chain, starting a fresh pending block on top of it.
+func (b *SimulatedBackend) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.seal(b.pendingBlock)
+	if err != nil {
+		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+	}
+	if _, err := b.blockchain.InsertChain([]*types.Block{block}); err != nil {
+		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+	}
+	b.rollback(block)
+}
+
+// Rollback aborts all pending transactions, reverting to the last committed state.
+func (b *SimulatedBackend) Rollback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollback(b.blockchain.CurrentBlock())
+}
+
+// Fork discards the pending block and starts a new one on top of the block with
+// the given hash. Blocks committed afterwards extend this side chain, which
+// becomes canonical once it is longer than the current one, allowing reorgs to
+// be simulated.
+func (b *SimulatedBackend) Fork(ctx context.Context, parentHash common.Hash) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pendingBlock.Transactions()) != 0 {
+		return errPendingBlockDirty
+	}
+	parent := b.blockchain.GetBlockByHash(parentHash)
+	if parent == nil {
+		return errBlockDoesNotExist
+	}
+	b.rollback(parent)
+	return nil
+}
+
+// AdjustTime moves the timestamp of the pending block forward by adjustment,
+// keeping its transactions. Blocks committed afterwards follow the new time.
+func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	if adjustment < 0 {
+		return errNegativeTimeAdjustment
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pendingShift += int64(adjustment / time.Millisecond)
+	b.generate(b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash()), b.pendingBlock.Transactions())
+	return nil
+}
+
+// rollback starts an empty pending block on top of parent.
+func (b *SimulatedBackend) rollback(parent *types.Block) {
+	b.pendingShift = 0
+	b.generate(parent, nil)
+}
+
+// generate replaces the pending block with one on top of parent holding txs.
+func (b *SimulatedBackend) generate(parent *types.Block, txs []*types.Transaction) {
+	blocks, _ := core.GenerateChain(b.config, parent, b.engine, b.database, 1, func(number int, block *core.BlockGen) {
+		extra := make([]byte, extraVanity)
+		if b.config.DPos.IsCheckpoint(block.Number().Uint64()) {
+			extra = append(extra, b.signer[:]...)
+		}
+		block.SetExtra(append(extra, make([]byte, extraSeal)...))
+		block.SetAuthor(b.signer)
+		if b.pendingShift > 0 {
+			block.OffsetTime(b.pendingShift)
+		}
+		for _, tx := range txs {
+			block.AddTxWithChain(b.blockchain, tx)
+		}
+	})
+	statedb, _ := b.blockchain.State()
+
+	b.pendingBlock = blocks[0]
+	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
+}
+
+// seal signs block as the simulated chain's signer.
+func (b *SimulatedBackend) seal(block *types.Block) (*types.Block, error) {
+	header := block.Header()
+	sig, err := crypto.Sign(epvdpos.SealHash(header).Bytes(), b.signerKey)
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	return block.WithSeal(header), nil
+}
+
+// stateByBlockNumber returns the state of the latest block, the only one the
+// simulated backend can serve.
+func (b *SimulatedBackend) stateByBlockNumber(blockNumber *big.Int) (*state.StateDB, error) {
+	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
+		return nil, errBlockNumberUnsupported
+	}
+	return b.blockchain.State()
+}
+
+// CodeAt returns the code associated with a certain account in the blockchain.
+func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.stateByBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(contract), nil
+}
+
+// BalanceAt returns the wei balance of a certain account in the blockchain.
+func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.stateByBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(contract), nil
+}
+
+// NonceAt returns the nonce of a certain account in the blockchain.
+func (b *SimulatedBackend) NonceAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.stateByBlockNumber(blockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return statedb.GetNonce(contract), nil
+}
+
+// StorageAt returns the value of key in the storage of an account in the blockchain.
+func (b *SimulatedBackend) StorageAt(ctx context.Context, contract common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.stateByBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	val := statedb.GetState(contract, key)
+	return val[:], nil
+}
+
+// HeaderByNumber returns the header of the block with the given number, or
+// the latest one if number is nil.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil {
+		return b.blockchain.CurrentHeader(), nil
+	}
+	header := b.blockchain.GetHeaderByNumber(number.Uint64())
+	if header == nil {
+		return nil, epvchain.NotFound
+	}
+	return header, nil
+}
+
+// TransactionReceipt returns the receipt of a transaction.
+func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, _, _, _ := core.GetReceipt(b.database, txHash)
+	if receipt == nil {
+		return nil, epvchain.NotFound
+	}
+	return receipt, nil
+}
+
+// PendingCodeAt returns the code associated with an account in the pending state.
+func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingState.GetCode(contract), nil
+}
+
+// CallContract executes a contract call.
+func (b *SimulatedBackend) CallContract(ctx context.Context, call epvchain.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.stateByBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	rval, _, _, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), statedb)
+	return rval, err
+}
+
+// PendingCallContract executes a contract call on the pending state.
+func (b *SimulatedBackend) PendingCallContract(ctx context.Context, call epvchain.CallMsg) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.pendingState.RevertToSnapshot(b.pendingState.Snapshot())
+
+	rval, _, _, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
+	return rval, err
+}
+
+// PendingNonceAt implements PendingStateReader.PendingNonceAt, retrieving
+// the nonce currently pending for the account.
+func (b *SimulatedBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingState.GetNonce(account), nil
+}
+
+// SuggestGasPrice implements ContractTransactor.SuggestGasPrice. Since the
+// simulated chain doesn't have miners, we just return the base fee of the
+// pending block, or a gas price of 1 before the base fee fork.
+func (b *SimulatedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if baseFee := b.pendingBlock.BaseFee(); baseFee != nil {
+		return new(big.Int).Set(baseFee), nil
+	}
+	return big.NewInt(1), nil
+}
+
+// EstimateGas executes the requested code against the pending state and
+// returns the lowest gas limit it succeeds with.
+func (b *SimulatedBackend) EstimateGas(ctx context.Context, call epvchain.CallMsg) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Determine the highest gas limit can be used during the estimation.
+	var (
+		lo  uint64 = params.TxGas - 1
+		hi  uint64
+		cap uint64
+	)
+	if call.Gas >= params.TxGas {
+		hi = call.Gas
+	} else {
+		hi = b.pendingBlock.GasLimit()
+	}
+	cap = hi
+
+	// Create a helper to check if a gas allowance results in an executable transaction
+	executable := func(gas uint64) bool {
+		call.Gas = gas
+
+		snapshot := b.pendingState.Snapshot()
+		_, _, failed, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
+		b.pendingState.RevertToSnapshot(snapshot)
+
+		if err != nil || failed {
+			return false
+		}
+		return true
+	}
+	// Execute the binary search and hone in on an executable gas limit
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		if !executable(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	// Reject the transaction as invalid if it still fails at the highest allowance
+	if hi == cap {
+		if !executable(hi) {
+			return 0, errGasEstimationFailed
+		}
+	}
+	return hi, nil
+}
+
+// callContract implements common code between normal and pending contract calls.
+// state is modified during execution, make sure to copy it if necessary.
+func (b *SimulatedBackend) callContract(ctx context.Context, call epvchain.CallMsg, block *types.Block, statedb *state.StateDB) ([]byte, uint64, bool, error) {
+	if call.GasPrice != nil && (call.GasFeeCap != nil || call.GasTipCap != nil) {
+		return nil, 0, false, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	gasPrice, gasFeeCap, gasTipCap := call.GasPrice, call.GasFeeCap, call.GasTipCap
+	switch {
+	case block.BaseFee() == nil:
+		if gasPrice == nil {
+			gasPrice = big.NewInt(1)
+		}
+		gasFeeCap, gasTipCap = gasPrice, gasPrice
+
+	case gasPrice != nil:
+		gasFeeCap, gasTipCap = gasPrice, gasPrice
+
+	default:
+		// Calls without any fee fields are executed free of charge.
+		if gasFeeCap == nil {
+			gasFeeCap = new(big.Int)
+		}
+		if gasTipCap == nil {
+			gasTipCap = new(big.Int)
+		}
+		gasPrice = new(big.Int)
+		if gasFeeCap.Sign() > 0 {
+			gasPrice = math.BigMin(new(big.Int).Add(gasTipCap, block.BaseFee()), gasFeeCap)
+		}
+	}
+	gas := call.Gas
+	if gas == 0 {
+		gas = 50000000
+	}
+	value := call.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	// Set infinite balance to the fake caller account.
+	statedb.SetBalance(call.From, math.MaxBig256)
+
+	msg := types.NewMessage(call.From, call.To, 0, value, gas, gasPrice, gasFeeCap, gasTipCap, call.Data, call.AccessList, false)
+	evmContext := core.NewEVMContext(msg, block.Header(), b.blockchain, &b.signer)
+	// Create a new environment which holds all relevant information
+	// about the transaction and calling mechanisms.
+	vmenv := vm.NewEVM(evmContext, statedb, b.config, vm.Config{NoBaseFee: true})
+	gaspool := new(core.GasPool).AddGas(math.MaxUint64)
+
+	return core.NewStateTransition(vmenv, msg, gaspool).TransitionDb()
+}
+
+// SendTransaction updates the pending block to include the given transaction.
+// The transaction is rejected if it cannot be executed on the pending state.
+func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var (
+		header  = b.pendingBlock.Header()
+		gaspool = new(core.GasPool).AddGas(header.GasLimit - header.GasUsed)
+		used    = header.GasUsed
+	)
+	if _, _, err := core.ApplyTransaction(b.config, b.blockchain, &b.signer, gaspool, b.pendingState.Copy(), header, tx, &used, vm.Config{}); err != nil {
+		return fmt.Errorf("invalid transaction: %v", err)
+	}
+	b.generate(b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash()), append(b.pendingBlock.Transactions(), tx))
+	return nil
+}
+
+// FilterLogs executes a log filter operation, blocking during execution and
+// returning all the results in one batch.
+func (b *SimulatedBackend) FilterLogs(ctx context.Context, query epvchain.FilterQuery) ([]types.Log, error) {
+	// Initialize unset filter boundaried to run from genesis to chain head
+	from := int64(0)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	to := int64(-1)
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	// Construct and execute the filter
+	filter := filters.New(&filterBackend{b.database, b.blockchain}, from, to, query.Addresses, query.Topics)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs creates a background log filtering operation, returning a
+// subscription immediately, which can be used to stream the found events.
+func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query epvchain.FilterQuery, ch chan<- types.Log) (epvchain.Subscription, error) {
+	// Subscribe to contract events
+	sink := make(chan []*types.Log)
+
+	sub, err := b.events.SubscribeLogs(query, sink)
+	if err != nil {
+		return nil, err
+	}
+	// Since we're getting logs in batches, we need to flatten them into a plain stream
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// filterBackend implements filters.Backend to support filtering for logs without
+// taking bloom-bits acceleration structures into account.
+type filterBackend struct {
+	db epvdb.Database
+	bc *core.BlockChain
+}
+
+func (fb *filterBackend) ChainDb() epvdb.Database  { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
+
+func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
+	if block == rpc.LatestBlockNumber {
+		return fb.bc.CurrentHeader(), nil
+	}
+	if block == rpc.FinalizedBlockNumber {
+		if finalized := fb.bc.FinalizedBlock(); finalized != nil {
+			return finalized.Header(), nil
+		}
+		return nil, nil
+	}
+	return fb.bc.GetHeaderByNumber(uint64(block.Int64())), nil
+}
+
+func (fb *filterBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(fb.db, hash, core.GetBlockNumber(fb.db, hash)), nil
+}
+
+func (fb *filterBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return fb.bc.SubscribeChainEvent(ch)
+}
+func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return fb.bc.SubscribeRemovedLogsEvent(ch)
+}
+func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return fb.bc.SubscribeLogsEvent(ch)
+}
+
+func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
+	panic("not supported")
+}
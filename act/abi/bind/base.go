@@ -0,0 +1,352 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain"
+	"github.com/epvchain/go-epvchain/act/abi"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+)
+
+// SignerFn is a signer function callback when a contract requires a method to
+// sign the transaction before submission.
+type SignerFn func(common.Address, *types.Transaction) (*types.Transaction, error)
+
+// CallOpts is the collection of options to fine tune a contract call request.
+type CallOpts struct {
+	Pending     bool            // Whether to operate on the pending state or the last known one
+	From        common.Address  // Optional the sender address, otherwise the first account is used
+	BlockNumber *big.Int        // Optional the block number on which the call should be performed
+	Context     context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// TransactOpts is the collection of authorization data required to create a
+// valid transaction.
+type TransactOpts struct {
+	From   common.Address // Sender of the transaction
+	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
+	Signer SignerFn       // Method to use for signing the transaction (mandatory)
+
+	Value     *big.Int // Funds to transfer along the transaction (nil = 0 = no funds)
+	GasPrice  *big.Int // Gas price for a legacy transaction (nil = dynamic fee or gas price oracle)
+	GasFeeCap *big.Int // Fee cap for a dynamic fee transaction (nil = tip plus twice the base fee)
+	GasTipCap *big.Int // Tip cap for a dynamic fee transaction (nil = gas price oracle)
+	GasLimit  uint64   // Gas limit to set for the transaction execution (0 = estimate)
+
+	ChainID *big.Int        // Chain to sign dynamic fee transactions for (nil = legacy transactions only)
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	Start   *uint64         // Start of the queried range (nil = latest)
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// BoundContract is the base wrapper object that reflects a contract on the
+// chain. It contains a collection of methods that are used by the higher
+// level contract bindings to operate.
+type BoundContract struct {
+	address    common.Address
+	abi        abi.ABI
+	caller     ContractCaller
+	transactor ContractTransactor
+	filterer   ContractFilterer
+}
+
+// NewBoundContract creates a low level contract interface through which calls
+// and transactions may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
+	return &BoundContract{
+		address:    address,
+		abi:        abi,
+		caller:     caller,
+		transactor: transactor,
+		filterer:   filterer,
+	}
+}
+
+// DeployContract deploys a contract onto the chain and wraps it with the Go
+// bindings.
+func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
+
+	input, err := c.abi.Pack("", params...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	tx, err := c.transact(opts, nil, append(bytecode, input...))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	c.address = crypto.CreateAddress(opts.From, tx.Nonce())
+	return c.address, tx, c, nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result.
+func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method string, params ...interface{}) error {
+	if results == nil {
+		results = new([]interface{})
+	}
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	var (
+		msg    = epvchain.CallMsg{From: opts.From, To: &c.address, Data: input}
+		ctx    = ensureContext(opts.Context)
+		code   []byte
+		output []byte
+	)
+	if opts.Pending {
+		pb, ok := c.caller.(PendingContractCaller)
+		if !ok {
+			return ErrNoPendingState
+		}
+		output, err = pb.PendingCallContract(ctx, msg)
+		if err == nil && len(output) == 0 {
+			if code, err = pb.PendingCodeAt(ctx, c.address); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	} else {
+		output, err = c.caller.CallContract(ctx, msg, opts.BlockNumber)
+		if err == nil && len(output) == 0 {
+			if code, err = c.caller.CodeAt(ctx, c.address, opts.BlockNumber); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(*results) == 0 {
+		res, err := c.abi.Unpack(method, output)
+		*results = res
+		return err
+	}
+	res := *results
+	return c.abi.UnpackIntoInterface(res[0], method, output)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(opts, &c.address, input)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract,
+// calling its default method if one is available.
+func (c *BoundContract) Transfer(opts *TransactOpts) (*types.Transaction, error) {
+	return c.transact(opts, &c.address, nil)
+}
+
+// transact executes an actual transaction invocation, first deriving any
+// missing authorization fields, and then scheduling the transaction for
+// execution. A dynamic fee transaction is built when no gas price is given,
+// a chain ID is known and the head block carries a base fee.
+func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, input []byte) (*types.Transaction, error) {
+	var err error
+
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	var nonce uint64
+	if opts.Nonce == nil {
+		nonce, err = c.transactor.PendingNonceAt(ensureContext(opts.Context), opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve account nonce: %v", err)
+		}
+	} else {
+		nonce = opts.Nonce.Uint64()
+	}
+	var head *types.Header
+	if opts.GasPrice == nil && opts.ChainID != nil {
+		if head, err = c.transactor.HeaderByNumber(ensureContext(opts.Context), nil); err != nil {
+			return nil, fmt.Errorf("failed to retrieve head header: %v", err)
+		}
+	}
+	var gasPrice, gasTipCap, gasFeeCap *big.Int
+	if head != nil && head.BaseFee != nil {
+		if gasTipCap = opts.GasTipCap; gasTipCap == nil {
+			price, err := c.transactor.SuggestGasPrice(ensureContext(opts.Context))
+			if err != nil {
+				return nil, fmt.Errorf("failed to suggest gas tip: %v", err)
+			}
+			gasTipCap = new(big.Int).Sub(price, head.BaseFee)
+			if gasTipCap.Sign() < 0 {
+				gasTipCap.SetInt64(0)
+			}
+		}
+		if gasFeeCap = opts.GasFeeCap; gasFeeCap == nil {
+			gasFeeCap = new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		}
+		if gasFeeCap.Cmp(gasTipCap) < 0 {
+			return nil, fmt.Errorf("max fee per gas (%v) < max priority fee per gas (%v)", gasFeeCap, gasTipCap)
+		}
+	} else if gasPrice = opts.GasPrice; gasPrice == nil {
+		if gasPrice, err = c.transactor.SuggestGasPrice(ensureContext(opts.Context)); err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		// Gas estimation cannot succeed without code for method invocations
+		if contract != nil {
+			if code, err := c.transactor.PendingCodeAt(ensureContext(opts.Context), c.address); err != nil {
+				return nil, err
+			} else if len(code) == 0 {
+				return nil, ErrNoCode
+			}
+		}
+		msg := epvchain.CallMsg{From: opts.From, To: contract, GasPrice: gasPrice, GasFeeCap: gasFeeCap, GasTipCap: gasTipCap, Value: value, Data: input}
+		gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+		}
+	}
+	var rawTx *types.Transaction
+	switch {
+	case gasFeeCap != nil:
+		rawTx = types.NewDynamicFeeTransaction(opts.ChainID, nonce, contract, value, gasLimit, gasTipCap, gasFeeCap, input, nil)
+	case contract == nil:
+		rawTx = types.NewContractCreation(nonce, value, gasLimit, gasPrice, input)
+	default:
+		rawTx = types.NewTransaction(nonce, c.address, value, gasLimit, gasPrice, input)
+	}
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	signedTx, err := opts.Signer(opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// FilterLogs filters contract logs for past blocks, returning the logs on a
+// channel together with a subscription to close the stream.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, epvchain.Subscription, error) {
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	topics, err := c.eventTopics(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := epvchain.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+		FromBlock: new(big.Int).SetUint64(opts.Start),
+	}
+	if opts.End != nil {
+		config.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	buff, err := c.filterer.FilterLogs(ensureContext(opts.Context), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	logs := make(chan types.Log, len(buff))
+	for _, log := range buff {
+		logs <- log
+	}
+	close(logs)
+	return logs, &pastSubscription{err: make(chan error)}, nil
+}
+
+// WatchLogs filters subscribes to contract logs for future blocks, returning
+// a subscription object that can be used to tear down the watcher.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan types.Log, epvchain.Subscription, error) {
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	topics, err := c.eventTopics(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := epvchain.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}
+	if opts.Start != nil {
+		config.FromBlock = new(big.Int).SetUint64(*opts.Start)
+	}
+	logs := make(chan types.Log, 128)
+
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, sub, nil
+}
+
+// eventTopics builds the topic filter of the named event, its ID followed by
+// the indexed argument rules.
+func (c *BoundContract) eventTopics(name string, query ...[]interface{}) ([][]common.Hash, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("abi: event %q not found", name)
+	}
+	if !event.Anonymous {
+		query = append([][]interface{}{{event.ID()}}, query...)
+	}
+	return abi.MakeTopics(query...)
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	return c.abi.UnpackLog(out, event, log)
+}
+
+// UnpackLogIntoMap unpacks a retrieved log into the provided map.
+func (c *BoundContract) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
+	return c.abi.UnpackLogIntoMap(out, event, log)
+}
+
+// pastSubscription is the subscription handed out for already retrieved logs,
+// which has nothing to tear down.
+type pastSubscription struct {
+	err chan error
+}
+
+func (s *pastSubscription) Unsubscribe()      {}
+func (s *pastSubscription) Err() <-chan error { return s.err }
+
+// ensureContext is a helper method to ensure a context is not nil, even if the
+// user specified it as such.
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.TODO()
+	}
+	return ctx
+}
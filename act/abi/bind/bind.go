@@ -0,0 +1,242 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"github.com/epvchain/go-epvchain/act/abi"
+)
+
+// Bind generates a Go wrapper around a contract ABI. The types, abis and
+// bytecodes slices describe one contract per index; a bytecode may be empty
+// if no deployment method should be generated.
+func Bind(types []string, abis []string, bytecodes []string, pkg string) (string, error) {
+	if len(types) != len(abis) || len(types) != len(bytecodes) {
+		return "", fmt.Errorf("mismatched contract definitions: %d types, %d abis, %d bytecodes", len(types), len(abis), len(bytecodes))
+	}
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct)
+	)
+	for i := 0; i < len(types); i++ {
+		evmABI, err := abi.JSON(strings.NewReader(abis[i]))
+		if err != nil {
+			return "", err
+		}
+		// Strip any whitespace from the JSON ABI
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(abis[i])); err != nil {
+			return "", err
+		}
+		contract := &tmplContract{
+			Type:        abi.ToCamelCase(types[i]),
+			InputABI:    compact.String(),
+			InputBin:    strings.TrimPrefix(strings.TrimSpace(bytecodes[i]), "0x"),
+			Constructor: normalizeMethod(evmABI.Constructor, structs),
+			Calls:       make(map[string]*tmplMethod),
+			Transacts:   make(map[string]*tmplMethod),
+			Events:      make(map[string]*tmplEvent),
+		}
+		if _, exist := contracts[contract.Type]; exist {
+			return "", fmt.Errorf("duplicated contract type %s", contract.Type)
+		}
+		for _, original := range evmABI.Methods {
+			normalized := normalizeMethod(original, structs)
+			method := &tmplMethod{
+				Original:   original,
+				Normalized: normalized,
+				Structured: structured(normalized.Outputs),
+			}
+			if original.Const {
+				contract.Calls[original.Name] = method
+			} else {
+				contract.Transacts[original.Name] = method
+			}
+		}
+		for _, original := range evmABI.Events {
+			normalized := original
+			normalized.Name = abi.ToCamelCase(original.Name)
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			fields := make([]*tmplField, len(original.Inputs))
+			for j, input := range normalized.Inputs {
+				if input.Name == "" || isKeyWord(input.Name) {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+				// Fields keep the ABI names so logs can be unpacked into them
+				field := &tmplField{Name: abi.ToCamelCase(input.Name), Type: bindStructType(input.Type, structs)}
+				if field.Name == "" {
+					field.Name = fmt.Sprintf("Arg%d", j)
+				}
+				if input.Indexed {
+					field.Type = bindTopicType(input.Type, structs)
+				}
+				fields[j] = field
+			}
+			contract.Events[original.Name] = &tmplEvent{Original: original, Normalized: normalized, Fields: fields}
+		}
+		contracts[contract.Type] = contract
+	}
+	data := &tmplData{
+		Package:   pkg,
+		Contracts: contracts,
+		Structs:   structs,
+	}
+	buffer := new(bytes.Buffer)
+
+	funcs := map[string]interface{}{
+		"bindtype":      func(kind abi.Type) string { return bindStructType(kind, structs) },
+		"bindtopictype": func(kind abi.Type) string { return bindTopicType(kind, structs) },
+		"capitalise":    abi.ToCamelCase,
+	}
+	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// normalizeMethod returns a copy of method with Go safe names for the method
+// and its arguments, registering any tuple types in structs.
+func normalizeMethod(method abi.Method, structs map[string]*tmplStruct) abi.Method {
+	normalized := method
+	normalized.Name = abi.ToCamelCase(method.Name)
+
+	normalized.Inputs = make([]abi.Argument, len(method.Inputs))
+	copy(normalized.Inputs, method.Inputs)
+	for j, input := range normalized.Inputs {
+		if input.Name == "" || isKeyWord(input.Name) {
+			normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+		}
+		bindStructType(input.Type, structs)
+	}
+	normalized.Outputs = make([]abi.Argument, len(method.Outputs))
+	copy(normalized.Outputs, method.Outputs)
+	for j, output := range normalized.Outputs {
+		if output.Name != "" {
+			normalized.Outputs[j].Name = abi.ToCamelCase(output.Name)
+		}
+		bindStructType(output.Type, structs)
+	}
+	return normalized
+}
+
+// structured reports whether the outputs are returned as one struct rather
+// than as separate values, which requires them to be named.
+func structured(outputs abi.Arguments) bool {
+	if len(outputs) < 2 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, out := range outputs {
+		if out.Name == "" || seen[out.Name] {
+			return false
+		}
+		seen[out.Name] = true
+	}
+	return true
+}
+
+// bindBasicType converts a non-composite ABI type to a Go type string.
+func bindBasicType(kind abi.Type) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return "common.Address"
+	case abi.IntTy, abi.UintTy:
+		switch kind.Size {
+		case 8, 16, 32, 64:
+			if kind.T == abi.UintTy {
+				return fmt.Sprintf("uint%d", kind.Size)
+			}
+			return fmt.Sprintf("int%d", kind.Size)
+		}
+		return "*big.Int"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", kind.Size)
+	case abi.BytesTy:
+		return "[]byte"
+	case abi.FunctionTy:
+		return "[24]byte"
+	case abi.HashTy:
+		return "common.Hash"
+	default:
+		return kind.GetType().String()
+	}
+}
+
+// bindStructType converts an ABI type to a Go type string, generating a named
+// struct for every distinct tuple type.
+func bindStructType(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		id := kind.TupleRawName + kind.String()
+		if s, exist := structs[id]; exist {
+			return s.Name
+		}
+		fields := make([]*tmplField, len(kind.TupleElems))
+		for i, elem := range kind.TupleElems {
+			fields[i] = &tmplField{
+				Name: kind.TupleType.Field(i).Name,
+				Type: bindStructType(*elem, structs),
+			}
+		}
+		name := abi.ToCamelCase(kind.TupleRawName)
+		if name == "" {
+			name = fmt.Sprintf("Struct%d", len(structs))
+		}
+		name = abi.ResolveNameConflict(name, func(s string) bool {
+			for _, st := range structs {
+				if st.Name == s {
+					return true
+				}
+			}
+			return false
+		})
+		structs[id] = &tmplStruct{Name: name, Fields: fields}
+		return name
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindStructType(*kind.Elem, structs)
+	case abi.SliceTy:
+		return "[]" + bindStructType(*kind.Elem, structs)
+	default:
+		return bindBasicType(kind)
+	}
+}
+
+// bindTopicType converts the type of an indexed event argument to the Go
+// type it is recovered as. Reference types are only logged as their hash.
+func bindTopicType(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return "common.Hash"
+	}
+	return bindStructType(kind, structs)
+}
+
+// reserved holds the Go keywords along with the identifiers the generated
+// code itself uses, neither of which may name a parameter.
+var reserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+
+	"abi": true, "big": true, "bind": true, "common": true, "epvchain": true,
+	"event": true, "strings": true, "types": true,
+	"auth": true, "backend": true, "contract": true, "err": true, "logs": true,
+	"opts": true, "out": true, "parsed": true, "sink": true, "sub": true,
+}
+
+// isKeyWord reports whether name cannot be used as a parameter name.
+func isKeyWord(name string) bool {
+	return reserved[name]
+}
@@ -0,0 +1,53 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrShortData = errors.New("data too short")
+
+	ErrBadOffset = errors.New("offset out of bounds")
+
+	ErrBadBool = errors.New("improperly encoded boolean value")
+
+	ErrBadPadding = errors.New("improperly padded value")
+
+	ErrOverflow = errors.New("integer out of range for type")
+
+	ErrEventSignatureMismatch = errors.New("abi: event signature mismatch")
+)
+
+// DecodeError reports ABI encoded data that cannot be decoded into Type. Err
+// is one of the Err* causes above.
+type DecodeError struct {
+	Type Type
+	Err  error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("abi: cannot decode %s: %v", err.Type, err.Err)
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// TypeError reports a Go value that cannot be encoded as Type.
+type TypeError struct {
+	Type Type
+	Got  string
+}
+
+func (err *TypeError) Error() string {
+	return fmt.Sprintf("abi: cannot use %s as type %s", err.Got, err.Type)
+}
+
+func typeErr(t Type, v reflect.Value) error {
+	if !v.IsValid() {
+		return &TypeError{Type: t, Got: "nil"}
+	}
+	return &TypeError{Type: t, Got: v.Type().String()}
+}
@@ -0,0 +1,43 @@
+package abi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/code"
+)
+
+// Event is a contract event. Indexed inputs are stored in the log topics,
+// the others in the log data.
+type Event struct {
+	Name      string
+	RawName   string
+	Anonymous bool
+	Inputs    Arguments
+}
+
+// Sig returns the canonical signature the event ID is derived from.
+func (e Event) Sig() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.RawName, strings.Join(types, ","))
+}
+
+// ID returns the hash logged as the first topic of non-anonymous events.
+func (e Event) ID() common.Hash {
+	return crypto.Keccak256Hash([]byte(e.Sig()))
+}
+
+func (e Event) String() string {
+	inputs := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		inputs[i] = fmt.Sprintf("%v %v", input.Type, input.Name)
+		if input.Indexed {
+			inputs[i] = fmt.Sprintf("%v indexed %v", input.Type, input.Name)
+		}
+	}
+	return fmt.Sprintf("event %v(%v)", e.RawName, strings.Join(inputs, ", "))
+}
@@ -0,0 +1,53 @@
+package abi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/epvchain/go-epvchain/code"
+)
+
+// Method is a callable contract function. Overloaded functions keep their
+// Solidity name in RawName and get a unique Name.
+type Method struct {
+	Name    string
+	RawName string
+	Const   bool
+	Payable bool
+	Inputs  Arguments
+	Outputs Arguments
+}
+
+// Sig returns the canonical signature the method ID is derived from, e.g.
+// "transfer(address,uint256)".
+func (method Method) Sig() string {
+	types := make([]string, len(method.Inputs))
+	for i, input := range method.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", method.RawName, strings.Join(types, ","))
+}
+
+// ID returns the four byte selector of the method.
+func (method Method) ID() []byte {
+	return crypto.Keccak256([]byte(method.Sig()))[:4]
+}
+
+func (method Method) String() string {
+	inputs := make([]string, len(method.Inputs))
+	for i, input := range method.Inputs {
+		inputs[i] = fmt.Sprintf("%v %v", input.Type, input.Name)
+	}
+	outputs := make([]string, len(method.Outputs))
+	for i, output := range method.Outputs {
+		outputs[i] = output.Type.String()
+		if len(output.Name) > 0 {
+			outputs[i] += fmt.Sprintf(" %v", output.Name)
+		}
+	}
+	constant := ""
+	if method.Const {
+		constant = "constant "
+	}
+	return fmt.Sprintf("function %v(%v) %vreturns(%v)", method.RawName, strings.Join(inputs, ", "), constant, strings.Join(outputs, ", "))
+}
@@ -0,0 +1,170 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/math"
+)
+
+var bigT = reflect.TypeOf(&big.Int{})
+
+// pack encodes v as a value of type t.
+func (t Type) pack(v reflect.Value) ([]byte, error) {
+	v = indirect(v)
+	if err := typeCheck(t, v); err != nil {
+		return nil, err
+	}
+
+	switch t.T {
+	case SliceTy, ArrayTy:
+		var head, tail []byte
+		if t.requiresLengthPrefix() {
+			head = packNum(reflect.ValueOf(v.Len()))
+		}
+		dynamic := isDynamicType(*t.Elem)
+		headSize := getTypeSize(*t.Elem) * v.Len()
+		for i := 0; i < v.Len(); i++ {
+			packed, err := t.Elem.pack(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if !dynamic {
+				head = append(head, packed...)
+				continue
+			}
+			head = append(head, packNum(reflect.ValueOf(headSize+len(tail)))...)
+			tail = append(tail, packed...)
+		}
+		return append(head, tail...), nil
+
+	case TupleTy:
+		fields, err := mapArgNamesToStructFields(t.TupleRawNames, v)
+		if err != nil {
+			return nil, err
+		}
+		headSize := 0
+		for _, elem := range t.TupleElems {
+			headSize += getTypeSize(*elem)
+		}
+		var head, tail []byte
+		for i, elem := range t.TupleElems {
+			field := v.FieldByName(fields[t.TupleRawNames[i]])
+			if !field.IsValid() {
+				return nil, typeErr(t, v)
+			}
+			packed, err := elem.pack(field)
+			if err != nil {
+				return nil, err
+			}
+			if isDynamicType(*elem) {
+				head = append(head, packNum(reflect.ValueOf(headSize+len(tail)))...)
+				tail = append(tail, packed...)
+			} else {
+				head = append(head, packed...)
+			}
+		}
+		return append(head, tail...), nil
+
+	default:
+		return packElement(t, v)
+	}
+}
+
+func packElement(t Type, v reflect.Value) ([]byte, error) {
+	switch t.T {
+	case IntTy, UintTy:
+		if v.Type() == bigT && !intInRange(t, v.Interface().(*big.Int)) {
+			return nil, &TypeError{Type: t, Got: "out of range " + v.Type().String()}
+		}
+		return packNum(v), nil
+	case StringTy:
+		return packBytesSlice([]byte(v.String())), nil
+	case AddressTy:
+		return common.LeftPadBytes(arrayBytes(v), 32), nil
+	case BoolTy:
+		if v.Bool() {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return math.PaddedBigBytes(common.Big0, 32), nil
+	case BytesTy:
+		return packBytesSlice(v.Bytes()), nil
+	case FixedBytesTy, FunctionTy:
+		return common.RightPadBytes(arrayBytes(v), 32), nil
+	default:
+		return nil, typeErr(t, v)
+	}
+}
+
+// packNum encodes an integer value as a 32 byte two's complement word.
+func packNum(v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return math.PaddedBigBytes(new(big.Int).SetUint64(v.Uint()), 32)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return math.PaddedBigBytes(math.U256(big.NewInt(v.Int())), 32)
+	case reflect.Ptr:
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(v.Interface().(*big.Int))), 32)
+	default:
+		panic("abi: invalid number type " + v.Type().String())
+	}
+}
+
+// packBytesSlice encodes b with its length prefix, padded to whole words.
+func packBytesSlice(b []byte) []byte {
+	enc := packNum(reflect.ValueOf(len(b)))
+	return append(enc, common.RightPadBytes(b, (len(b)+31)/32*32)...)
+}
+
+// arrayBytes returns the contents of a byte array value.
+func arrayBytes(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// intInRange reports whether x fits into the integer type t.
+func intInRange(t Type, x *big.Int) bool {
+	if t.T == UintTy {
+		return x.Sign() >= 0 && x.BitLen() <= t.Size
+	}
+	if x.Sign() < 0 {
+		return new(big.Int).Add(x, common.Big1).BitLen() <= t.Size-1
+	}
+	return x.BitLen() <= t.Size-1
+}
+
+// typeCheck reports whether v can be encoded as a value of type t.
+func typeCheck(t Type, v reflect.Value) error {
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return typeErr(t, v)
+	}
+	switch t.T {
+	case SliceTy, ArrayTy:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return typeErr(t, v)
+		}
+		if t.T == ArrayTy && v.Len() != t.Size {
+			return typeErr(t, v)
+		}
+		return nil
+	case AddressTy, FixedBytesTy, FunctionTy:
+		if v.Kind() != reflect.Array || v.Type().Elem().Kind() != reflect.Uint8 || v.Len() != t.Size {
+			return typeErr(t, v)
+		}
+		return nil
+	case BytesTy:
+		if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+			return typeErr(t, v)
+		}
+		return nil
+	}
+	if t.GetType().Kind() != v.Kind() {
+		return typeErr(t, v)
+	}
+	if v.Kind() == reflect.Ptr && v.Type() != bigT {
+		return typeErr(t, v)
+	}
+	return nil
+}
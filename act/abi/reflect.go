@@ -0,0 +1,180 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// indirect dereferences v down to its value, keeping *big.Int pointers.
+func indirect(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr && v.Type() != bigT && !v.IsNil() {
+		return indirect(v.Elem())
+	}
+	return v
+}
+
+// ConvertType converts a decoded value into the type proto points to, e.g.
+// an anonymous tuple struct into a generated struct type, and returns proto.
+func ConvertType(in interface{}, proto interface{}) interface{} {
+	protoType := reflect.TypeOf(proto)
+	if reflect.TypeOf(in).ConvertibleTo(protoType) {
+		return reflect.ValueOf(in).Convert(protoType).Interface()
+	}
+	if err := set(reflect.ValueOf(proto), reflect.ValueOf(in)); err != nil {
+		panic(err)
+	}
+	return proto
+}
+
+// set assigns the decoded value src to dst, converting between the decoded
+// Go types and compatible user types.
+func set(dst, src reflect.Value) error {
+	dstType, srcType := dst.Type(), src.Type()
+	switch {
+	case dstType.Kind() == reflect.Interface && dst.Elem().IsValid() && (dst.Elem().Kind() == reflect.Ptr || dst.Elem().CanSet()):
+		return set(dst.Elem(), src)
+	case dstType.Kind() == reflect.Ptr && dstType != bigT:
+		if dst.IsNil() {
+			if !dst.CanSet() {
+				return errors.New("abi: cannot set nil pointer")
+			}
+			dst.Set(reflect.New(dstType.Elem()))
+		}
+		return set(dst.Elem(), src)
+	case srcType.AssignableTo(dstType) && dst.CanSet():
+		dst.Set(src)
+	case srcType.ConvertibleTo(dstType) && dst.CanSet() && srcType.Kind() == dstType.Kind() && srcType.Kind() != reflect.Struct:
+		dst.Set(src.Convert(dstType))
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice && dst.CanSet():
+		return setSlice(dst, src)
+	case dstType.Kind() == reflect.Array && (srcType.Kind() == reflect.Array || srcType.Kind() == reflect.Slice):
+		return setArray(dst, src)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct:
+		return setStruct(dst, src)
+	default:
+		return fmt.Errorf("abi: cannot unmarshal %v into %v", srcType, dstType)
+	}
+	return nil
+}
+
+func setSlice(dst, src reflect.Value) error {
+	slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		if err := set(slice.Index(i), src.Index(i)); err != nil {
+			return err
+		}
+	}
+	dst.Set(slice)
+	return nil
+}
+
+func setArray(dst, src reflect.Value) error {
+	if src.Len() != dst.Len() {
+		return fmt.Errorf("abi: cannot unmarshal %v into %v", src.Type(), dst.Type())
+	}
+	if !dst.CanSet() {
+		return errors.New("abi: cannot set array, destination not settable")
+	}
+	array := reflect.New(dst.Type()).Elem()
+	for i := 0; i < src.Len(); i++ {
+		if err := set(array.Index(i), src.Index(i)); err != nil {
+			return err
+		}
+	}
+	dst.Set(array)
+	return nil
+}
+
+func setStruct(dst, src reflect.Value) error {
+	for i := 0; i < src.NumField(); i++ {
+		name := src.Type().Field(i).Name
+		field := dst.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in %v", name, dst.Type())
+		}
+		if err := set(field, src.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapArgNamesToStructFields maps ABI argument names to the fields of the
+// struct value, either through an `abi:"name"` tag or by the camel cased
+// argument name.
+func mapArgNamesToStructFields(names []string, value reflect.Value) (map[string]string, error) {
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("abi: cannot map arguments to %v", value.Type())
+	}
+	var (
+		typ        = value.Type()
+		abi2struct = make(map[string]string)
+		struct2abi = make(map[string]string)
+	)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup("abi")
+		if !ok {
+			continue
+		}
+		if tag == "" {
+			return nil, fmt.Errorf("abi: empty abi tag on field %s", field.Name)
+		}
+		found := false
+		for _, name := range names {
+			if name != tag {
+				continue
+			}
+			if abi2struct[name] != "" {
+				return nil, fmt.Errorf("abi: tag %q mapped twice", tag)
+			}
+			abi2struct[name] = field.Name
+			struct2abi[field.Name] = name
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("abi: tag %q on field %s not found in abi", tag, field.Name)
+		}
+	}
+	for _, name := range names {
+		if abi2struct[name] != "" {
+			continue
+		}
+		fieldName := ToCamelCase(name)
+		if fieldName == "" {
+			return nil, errors.New("abi: purely underscored argument cannot map to a struct field")
+		}
+		if struct2abi[fieldName] != "" {
+			return nil, fmt.Errorf("abi: multiple arguments map to struct field %s", fieldName)
+		}
+		if _, ok := typ.FieldByName(fieldName); ok {
+			abi2struct[name] = fieldName
+			struct2abi[fieldName] = name
+		}
+	}
+	return abi2struct, nil
+}
+
+// ToCamelCase converts an underscored name to its exported Go form, e.g.
+// "_my_value" to "MyValue".
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, s := range parts {
+		if len(s) > 0 {
+			parts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// ResolveNameConflict returns rawName, or rawName suffixed with the first
+// free index if used reports it taken.
+func ResolveNameConflict(rawName string, used func(string) bool) string {
+	name := rawName
+	for idx := 0; used(name); idx++ {
+		name = fmt.Sprintf("%s%d", rawName, idx)
+	}
+	return name
+}
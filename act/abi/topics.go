@@ -0,0 +1,115 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/math"
+	"github.com/epvchain/go-epvchain/code"
+)
+
+var errTopicCount = errors.New("abi: topic count mismatch")
+
+// MakeTopics converts lists of indexed argument values into filter topic
+// sets, one set per indexed argument. Strings, byte slices and other
+// reference types are matched by their hash.
+func MakeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			switch rule := rule.(type) {
+			case common.Hash:
+				topic = rule
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], math.PaddedBigBytes(math.U256(new(big.Int).Set(rule)), 32))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8, int16, int32, int64:
+				copy(topic[:], packNum(reflect.ValueOf(rule)))
+			case uint8, uint16, uint32, uint64:
+				copy(topic[:], packNum(reflect.ValueOf(rule)))
+			case string:
+				topic = crypto.Keccak256Hash([]byte(rule))
+			case []byte:
+				topic = crypto.Keccak256Hash(rule)
+			default:
+				val := reflect.ValueOf(rule)
+				if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+					return nil, fmt.Errorf("abi: unsupported indexed type: %T", rule)
+				}
+				copy(topic[:], arrayBytes(val))
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// ParseTopics stores the indexed arguments held in topics into the fields of
+// the struct out points to.
+func ParseTopics(out interface{}, fields Arguments, topics []common.Hash) error {
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("abi: cannot parse topics into %T", out)
+	}
+	names := make([]string, len(fields))
+	for i, arg := range fields {
+		names[i] = arg.Name
+	}
+	abi2struct, err := mapArgNamesToStructFields(names, value.Elem())
+	if err != nil {
+		return err
+	}
+	return parseTopicWithSetter(fields, topics, func(arg Argument, reconstr interface{}) error {
+		field := value.Elem().FieldByName(abi2struct[arg.Name])
+		if !field.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in the given value", arg.Name)
+		}
+		return set(field, reflect.ValueOf(reconstr))
+	})
+}
+
+// ParseTopicsIntoMap stores the indexed arguments held in topics into out by
+// argument name.
+func ParseTopicsIntoMap(out map[string]interface{}, fields Arguments, topics []common.Hash) error {
+	return parseTopicWithSetter(fields, topics, func(arg Argument, reconstr interface{}) error {
+		out[arg.Name] = reconstr
+		return nil
+	})
+}
+
+// parseTopicWithSetter decodes every topic as its indexed argument. Values of
+// reference types are only logged as their hash and decode to common.Hash.
+func parseTopicWithSetter(fields Arguments, topics []common.Hash, setter func(Argument, interface{}) error) error {
+	if len(fields) != len(topics) {
+		return errTopicCount
+	}
+	for i, arg := range fields {
+		if !arg.Indexed {
+			return errors.New("abi: non-indexed field in topic reconstruction")
+		}
+		var reconstr interface{}
+		switch arg.Type.T {
+		case StringTy, BytesTy, SliceTy, ArrayTy, TupleTy:
+			reconstr = topics[i]
+		default:
+			var err error
+			if reconstr, err = toGoType(0, arg.Type, topics[i].Bytes()); err != nil {
+				return err
+			}
+		}
+		if err := setter(arg, reconstr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
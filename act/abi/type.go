@@ -0,0 +1,283 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/epvchain/go-epvchain/public"
+)
+
+const (
+	IntTy byte = iota
+	UintTy
+	BoolTy
+	StringTy
+	SliceTy
+	ArrayTy
+	TupleTy
+	AddressTy
+	FixedBytesTy
+	BytesTy
+	HashTy
+	FixedPointTy
+	FunctionTy
+)
+
+// Type is a parsed Solidity ABI type.
+type Type struct {
+	Elem *Type
+	Size int
+	T    byte
+
+	TupleRawName  string
+	TupleElems    []*Type
+	TupleRawNames []string
+	TupleType     reflect.Type
+
+	stringKind string
+}
+
+var (
+	typeRegex = regexp.MustCompile("^([a-zA-Z]+)(([0-9]+)(x([0-9]+))?)?$")
+
+	fieldRegex = regexp.MustCompile("^[A-Z][a-zA-Z0-9_]*$")
+)
+
+// NewType parses the type string of an ABI argument. Tuple types take their
+// fields from components and their name from a "struct" internalType.
+func NewType(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
+	if strings.Count(t, "[") != strings.Count(t, "]") {
+		return Type{}, fmt.Errorf("abi: invalid arg type %q", t)
+	}
+	typ.stringKind = t
+
+	if strings.Count(t, "[") != 0 {
+		i := strings.LastIndex(t, "[")
+		if !strings.HasSuffix(t, "]") {
+			return Type{}, fmt.Errorf("abi: invalid arg type %q", t)
+		}
+		if j := strings.LastIndex(internalType, "["); j >= 0 && strings.HasSuffix(internalType, "]") {
+			internalType = internalType[:j]
+		}
+		embeddedType, err := NewType(t[:i], internalType, components)
+		if err != nil {
+			return Type{}, err
+		}
+		sliced := t[i:]
+		typ.Elem = &embeddedType
+		typ.stringKind = embeddedType.stringKind + sliced
+		if size := sliced[1 : len(sliced)-1]; size == "" {
+			typ.T = SliceTy
+		} else {
+			typ.T = ArrayTy
+			if typ.Size, err = strconv.Atoi(size); err != nil || typ.Size <= 0 {
+				return Type{}, fmt.Errorf("abi: invalid array size in %q", t)
+			}
+		}
+		return typ, nil
+	}
+
+	parsed := typeRegex.FindStringSubmatch(t)
+	if parsed == nil {
+		return Type{}, fmt.Errorf("abi: invalid arg type %q", t)
+	}
+	var varSize int
+	if len(parsed[3]) > 0 {
+		if varSize, err = strconv.Atoi(parsed[3]); err != nil {
+			return Type{}, fmt.Errorf("abi: invalid arg type %q", t)
+		}
+	} else if parsed[1] == "int" || parsed[1] == "uint" {
+		return Type{}, fmt.Errorf("abi: unsupported arg type %q", t)
+	}
+
+	switch parsed[1] {
+	case "int", "uint":
+		if varSize == 0 || varSize > 256 || varSize%8 != 0 {
+			return Type{}, fmt.Errorf("abi: invalid integer size in %q", t)
+		}
+		typ.Size = varSize
+		typ.T = IntTy
+		if parsed[1] == "uint" {
+			typ.T = UintTy
+		}
+	case "bool":
+		typ.T = BoolTy
+	case "address":
+		typ.Size = common.AddressLength
+		typ.T = AddressTy
+	case "string":
+		typ.T = StringTy
+	case "bytes":
+		if varSize == 0 {
+			typ.T = BytesTy
+		} else {
+			if varSize > 32 {
+				return Type{}, fmt.Errorf("abi: invalid fixed bytes size in %q", t)
+			}
+			typ.T = FixedBytesTy
+			typ.Size = varSize
+		}
+	case "function":
+		typ.T = FunctionTy
+		typ.Size = 24
+	case "tuple":
+		if len(components) == 0 {
+			return Type{}, errors.New("abi: tuple without components")
+		}
+		var (
+			fields []reflect.StructField
+			elems  []*Type
+			names  []string
+			kinds  []string
+			used   = make(map[string]bool)
+		)
+		for _, c := range components {
+			cType, err := NewType(c.Type, c.InternalType, c.Components)
+			if err != nil {
+				return Type{}, err
+			}
+			name := ToCamelCase(c.Name)
+			if name == "" {
+				return Type{}, errors.New("abi: purely anonymous or underscored tuple field is not supported")
+			}
+			name = ResolveNameConflict(name, func(s string) bool { return used[s] })
+			if !fieldRegex.MatchString(name) {
+				return Type{}, fmt.Errorf("abi: invalid tuple field name %q", c.Name)
+			}
+			used[name] = true
+
+			fields = append(fields, reflect.StructField{
+				Name: name,
+				Type: cType.GetType(),
+				Tag:  reflect.StructTag(`json:"` + c.Name + `"`),
+			})
+			elems = append(elems, &cType)
+			names = append(names, c.Name)
+			kinds = append(kinds, cType.stringKind)
+		}
+		typ.T = TupleTy
+		if strings.HasPrefix(internalType, "struct ") {
+			// Nested definitions like Foo.Bar are flattened to FooBar.
+			typ.TupleRawName = strings.Replace(internalType[len("struct "):], ".", "", -1)
+		}
+		typ.TupleType = reflect.StructOf(fields)
+		typ.TupleElems = elems
+		typ.TupleRawNames = names
+		typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+	default:
+		return Type{}, fmt.Errorf("abi: unsupported arg type %q", t)
+	}
+	return typ, nil
+}
+
+// GetType returns the Go type values of this ABI type are decoded into.
+func (t Type) GetType() reflect.Type {
+	switch t.T {
+	case IntTy:
+		return reflectIntType(false, t.Size)
+	case UintTy:
+		return reflectIntType(true, t.Size)
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case SliceTy:
+		return reflect.SliceOf(t.Elem.GetType())
+	case ArrayTy:
+		return reflect.ArrayOf(t.Size, t.Elem.GetType())
+	case TupleTy:
+		return t.TupleType
+	case AddressTy:
+		return reflect.TypeOf(common.Address{})
+	case FixedBytesTy:
+		return reflect.ArrayOf(t.Size, reflect.TypeOf(byte(0)))
+	case BytesTy:
+		return reflect.TypeOf([]byte{})
+	case HashTy:
+		return reflect.TypeOf(common.Hash{})
+	case FixedPointTy:
+		return reflect.ArrayOf(32, reflect.TypeOf(byte(0)))
+	case FunctionTy:
+		return reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
+	default:
+		panic("abi: invalid type")
+	}
+}
+
+// String returns the canonical type string used in signatures.
+func (t Type) String() string {
+	return t.stringKind
+}
+
+func reflectIntType(unsigned bool, size int) reflect.Type {
+	if unsigned {
+		switch size {
+		case 8:
+			return reflect.TypeOf(uint8(0))
+		case 16:
+			return reflect.TypeOf(uint16(0))
+		case 32:
+			return reflect.TypeOf(uint32(0))
+		case 64:
+			return reflect.TypeOf(uint64(0))
+		}
+	}
+	switch size {
+	case 8:
+		return reflect.TypeOf(int8(0))
+	case 16:
+		return reflect.TypeOf(int16(0))
+	case 32:
+		return reflect.TypeOf(int32(0))
+	case 64:
+		return reflect.TypeOf(int64(0))
+	}
+	return reflect.TypeOf(&big.Int{})
+}
+
+// requiresLengthPrefix reports whether values of t are preceded by their
+// length when encoded.
+func (t Type) requiresLengthPrefix() bool {
+	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy
+}
+
+// isDynamicType reports whether values of t are encoded in the tail, with
+// only an offset in the head.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return isDynamicType(*t.Elem)
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getTypeSize returns the size a value of t takes in the head of an encoding.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.Size * getTypeSize(*t.Elem)
+	case TupleTy:
+		total := 0
+		for _, elem := range t.TupleElems {
+			total += getTypeSize(*elem)
+		}
+		return total
+	}
+	return 32
+}
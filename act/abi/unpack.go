@@ -0,0 +1,179 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/math"
+)
+
+// toGoType decodes the value of type t whose head starts at index in output.
+// Offsets of dynamic values are relative to the start of output.
+func toGoType(index int, t Type, output []byte) (interface{}, error) {
+	if index < 0 || index+32 > len(output) {
+		return nil, &DecodeError{t, ErrShortData}
+	}
+	word := output[index : index+32]
+
+	switch t.T {
+	case TupleTy:
+		if isDynamicType(t) {
+			begin, err := offsetAt(t, word, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
+
+	case SliceTy:
+		begin, length, err := lengthPrefixPointsTo(t, word, output)
+		if err != nil {
+			return nil, err
+		}
+		return forEachUnpack(t, output[begin:], length)
+
+	case ArrayTy:
+		if isDynamicType(*t.Elem) {
+			begin, err := offsetAt(t, word, output)
+			if err != nil {
+				return nil, err
+			}
+			return forEachUnpack(t, output[begin:], t.Size)
+		}
+		return forEachUnpack(t, output[index:], t.Size)
+
+	case StringTy, BytesTy:
+		begin, length, err := lengthPrefixPointsTo(t, word, output)
+		if err != nil {
+			return nil, err
+		}
+		if begin+length > len(output) {
+			return nil, &DecodeError{t, ErrShortData}
+		}
+		if t.T == StringTy {
+			return string(output[begin : begin+length]), nil
+		}
+		return common.CopyBytes(output[begin : begin+length]), nil
+
+	case IntTy, UintTy:
+		return readInteger(t, word)
+
+	case BoolTy:
+		if !isZero(word[:31]) || word[31] > 1 {
+			return nil, &DecodeError{t, ErrBadBool}
+		}
+		return word[31] == 1, nil
+
+	case AddressTy:
+		if !isZero(word[:12]) {
+			return nil, &DecodeError{t, ErrBadPadding}
+		}
+		return common.BytesToAddress(word), nil
+
+	case HashTy:
+		return common.BytesToHash(word), nil
+
+	case FixedBytesTy, FunctionTy:
+		if !isZero(word[t.Size:]) {
+			return nil, &DecodeError{t, ErrBadPadding}
+		}
+		array := reflect.New(t.GetType()).Elem()
+		reflect.Copy(array, reflect.ValueOf(word[:t.Size]))
+		return array.Interface(), nil
+
+	default:
+		return nil, typeErr(t, reflect.Value{})
+	}
+}
+
+// forEachUnpack decodes size consecutive elements of a slice or array type.
+func forEachUnpack(t Type, output []byte, size int) (interface{}, error) {
+	elemSize := getTypeSize(*t.Elem)
+	if size < 0 || size > len(output)/elemSize {
+		return nil, &DecodeError{t, ErrShortData}
+	}
+	var values reflect.Value
+	if t.T == SliceTy {
+		values = reflect.MakeSlice(t.GetType(), size, size)
+	} else {
+		values = reflect.New(t.GetType()).Elem()
+	}
+	for i := 0; i < size; i++ {
+		value, err := toGoType(i*elemSize, *t.Elem, output)
+		if err != nil {
+			return nil, err
+		}
+		values.Index(i).Set(reflect.ValueOf(value))
+	}
+	return values.Interface(), nil
+}
+
+// forTupleUnpack decodes the fields of a tuple whose encoding starts output.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	value := reflect.New(t.GetType()).Elem()
+	offset := 0
+	for i, elem := range t.TupleElems {
+		field, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(*elem)
+		value.Field(i).Set(reflect.ValueOf(field))
+	}
+	return value.Interface(), nil
+}
+
+// offsetAt returns the offset stored in word, checked against output.
+func offsetAt(t Type, word []byte, output []byte) (int, error) {
+	offset := new(big.Int).SetBytes(word)
+	if offset.BitLen() > 63 || offset.Uint64() > uint64(len(output)) {
+		return 0, &DecodeError{t, ErrBadOffset}
+	}
+	return int(offset.Uint64()), nil
+}
+
+// lengthPrefixPointsTo follows the offset in word to a length prefix and
+// returns where the data after it begins, and the length.
+func lengthPrefixPointsTo(t Type, word []byte, output []byte) (int, int, error) {
+	offset, err := offsetAt(t, word, output)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset+32 > len(output) {
+		return 0, 0, &DecodeError{t, ErrBadOffset}
+	}
+	length := new(big.Int).SetBytes(output[offset : offset+32])
+	if length.BitLen() > 63 || length.Uint64() > uint64(len(output)) {
+		return 0, 0, &DecodeError{t, ErrShortData}
+	}
+	return offset + 32, int(length.Uint64()), nil
+}
+
+// readInteger decodes an integer word, rejecting values that do not fit t.
+func readInteger(t Type, word []byte) (interface{}, error) {
+	x := new(big.Int).SetBytes(word)
+	if t.T == IntTy {
+		x = math.S256(x)
+	}
+	if !intInRange(t, x) {
+		return nil, &DecodeError{t, ErrOverflow}
+	}
+	switch typ := t.GetType(); typ.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(x.Uint64()).Convert(typ).Interface(), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(x.Int64()).Convert(typ).Interface(), nil
+	}
+	return x, nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
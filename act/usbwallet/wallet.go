@@ -420,6 +420,9 @@ func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 	if !ok {
 		return nil, accounts.ErrUnknownAccount
 	}
+	if tx.Type() != types.LegacyTxType {
+		return nil, accounts.ErrNotSupported
+	}
 
 	<-w.commsLock
 	defer func() { w.commsLock <- struct{}{} }()
@@ -2,13 +2,15 @@ package epvdpos
 
 import (
 	"bytes"
-	"encoding/json"
 	"encoding/binary"
+	"math/big"
 	"math/rand"
 
 	"github.com/epvchain/go-epvchain/public"
 	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
 	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
 	"github.com/epvchain/go-epvchain/content"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -25,6 +27,12 @@ type Vote struct {
 	Authorize bool           `json:"authorize"`
 }
 
+type SignerStats struct {
+	Produced     uint64 `json:"produced"`
+	MissedInturn uint64 `json:"missedInturn"`
+	OutOfTurn    uint64 `json:"outOfTurn"`
+}
+
 type Archive struct {
 	config   *params.DPosConfig
 	sigcache *lru.ARCCache
@@ -35,6 +43,15 @@ type Archive struct {
 	Recents map[uint64]common.Address   `json:"recents"`
 	Votes   []*Vote                     `json:"votes"`
 	Tally   map[common.Address]Tally    `json:"tally"`
+
+	Stats  map[common.Address]*SignerStats `json:"stats"`
+	Misses map[common.Address]uint64       `json:"misses"`
+	Jailed map[common.Address]uint64       `json:"jailed"`
+
+	// pending is the earliest election checkpoint whose signer list was
+	// adopted without the parent state to check it against. Such archives
+	// are kept in memory only and rebuilt once the state is available.
+	pending *types.Header
 }
 
 func newArchive(config *params.DPosConfig, sigcache *lru.ARCCache, number uint64, hash common.Hash, signers []common.Address) *Archive {
@@ -46,6 +63,9 @@ func newArchive(config *params.DPosConfig, sigcache *lru.ARCCache, number uint64
 		Signers:  make(map[common.Address]struct{}),
 		Recents:  make(map[uint64]common.Address),
 		Tally:    make(map[common.Address]Tally),
+		Stats:    make(map[common.Address]*SignerStats),
+		Misses:   make(map[common.Address]uint64),
+		Jailed:   make(map[common.Address]uint64),
 	}
 	for _, signer := range signers {
 		archive.Signers[signer] = struct{}{}
@@ -54,26 +74,32 @@ func newArchive(config *params.DPosConfig, sigcache *lru.ARCCache, number uint64
 }
 
 func loadArchive(config *params.DPosConfig, sigcache *lru.ARCCache, db epvdb.Database, hash common.Hash) (*Archive, error) {
-	blob, err := db.Get(append([]byte("epvdpos-"), hash[:]...))
+	blob, err := db.Get(archiveKey(hash))
 	if err != nil {
 		return nil, err
 	}
-	archive := new(Archive)
-	if err := json.Unmarshal(blob, archive); err != nil {
+	archive, legacy, err := decodeArchive(blob)
+	if err != nil {
 		return nil, err
 	}
 	archive.config = config
 	archive.sigcache = sigcache
 
+	if legacy {
+		if err := archive.store(db); err != nil {
+			return nil, err
+		}
+		log.Trace("Migrated legacy voting archive", "number", archive.Number, "hash", archive.Hash)
+	}
 	return archive, nil
 }
 
 func (s *Archive) store(db epvdb.Database) error {
-	blob, err := json.Marshal(s)
+	blob, err := encodeArchive(s)
 	if err != nil {
 		return err
 	}
-	return db.Put(append([]byte("epvdpos-"), s.Hash[:]...), blob)
+	return db.Put(archiveKey(s.Hash), blob)
 }
 
 func (s *Archive) copy() *Archive {
@@ -86,6 +112,10 @@ func (s *Archive) copy() *Archive {
 		Recents:  make(map[uint64]common.Address),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+		Stats:    make(map[common.Address]*SignerStats),
+		Misses:   make(map[common.Address]uint64),
+		Jailed:   make(map[common.Address]uint64),
+		pending:  s.pending,
 	}
 	for signer := range s.Signers {
 		cpy.Signers[signer] = struct{}{}
@@ -98,6 +128,16 @@ func (s *Archive) copy() *Archive {
 	}
 	copy(cpy.Votes, s.Votes)
 
+	for signer, stats := range s.Stats {
+		cpy.Stats[signer] = &SignerStats{stats.Produced, stats.MissedInturn, stats.OutOfTurn}
+	}
+	for signer, misses := range s.Misses {
+		cpy.Misses[signer] = misses
+	}
+	for signer, block := range s.Jailed {
+		cpy.Jailed[signer] = block
+	}
+
 	return cpy
 }
 
@@ -152,9 +192,12 @@ func (s *Archive) apply(headers []*types.Header) (*Archive, error) {
 
 	for _, header := range headers {
 		number := header.Number.Uint64()
-		if number%s.config.Epoch == 0 {
+		expected, hasTurn := archive.inturnSigner(number, header.ParentHash)
+
+		if s.config.IsCheckpoint(number) {
 			archive.Votes = nil
 			archive.Tally = make(map[common.Address]Tally)
+			archive.Misses = make(map[common.Address]uint64)
 		}
 		if limit := uint64(len(archive.Signers)/2 + 1); number >= limit {
 			delete(archive.Recents, number-limit)
@@ -172,7 +215,18 @@ func (s *Archive) apply(headers []*types.Header) (*Archive, error) {
 			}
 		}
 		archive.Recents[number] = signer
+		archive.track(header, signer, expected, hasTurn)
 
+		if isRegistry(s.config, header.Number) {
+			if s.config.IsCheckpoint(number) {
+				archive.elect(header)
+			} else {
+				archive.drop(header)
+			}
+		}
+		if s.config.IsElection(header.Number) {
+			continue
+		}
 		for i, vote := range archive.Votes {
 			if vote.Signer == signer && vote.Address == header.Coinbase {
 				archive.uncast(vote.Address, vote.Authorize)
@@ -232,6 +286,85 @@ func (s *Archive) apply(headers []*types.Header) (*Archive, error) {
 	return archive, nil
 }
 
+func (s *Archive) stats(signer common.Address) *SignerStats {
+	stats, ok := s.Stats[signer]
+	if !ok {
+		stats = new(SignerStats)
+		s.Stats[signer] = stats
+	}
+	return stats
+}
+
+func (s *Archive) track(header *types.Header, signer, expected common.Address, hasTurn bool) {
+	liveness := s.config.IsLiveness(header.Number)
+	if liveness {
+		if _, jailed := s.Jailed[signer]; jailed {
+			delete(s.Jailed, signer)
+			delete(s.Misses, signer)
+		}
+	}
+	s.stats(signer).Produced++
+
+	if header.Difficulty.Cmp(diffInTurn) == 0 {
+		return
+	}
+	s.stats(signer).OutOfTurn++
+
+	if !hasTurn || expected == signer {
+		return
+	}
+	s.stats(expected).MissedInturn++
+
+	if liveness {
+		s.Misses[expected]++
+		if s.Misses[expected] >= s.config.MissThreshold {
+			s.Jailed[expected] = header.Number.Uint64()
+		}
+	}
+}
+
+func (s *Archive) elect(header *types.Header) {
+	s.Signers = make(map[common.Address]struct{})
+	for _, signer := range headerSigners(header) {
+		s.Signers[signer] = struct{}{}
+	}
+	s.trimRecents(header.Number.Uint64())
+}
+
+// drop removes the signers a block slashed for double signing, along with
+// the votes they cast.
+func (s *Archive) drop(header *types.Header) {
+	slashed := headerSigners(header)
+	if len(slashed) == 0 {
+		return
+	}
+	for _, signer := range slashed {
+		delete(s.Signers, signer)
+		delete(s.Misses, signer)
+		delete(s.Jailed, signer)
+
+		for i := 0; i < len(s.Votes); i++ {
+			if s.Votes[i].Signer == signer {
+				s.uncast(s.Votes[i].Address, s.Votes[i].Authorize)
+
+				s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+				i--
+			}
+		}
+	}
+	s.trimRecents(header.Number.Uint64())
+}
+
+func (s *Archive) trimRecents(number uint64) {
+	if limit := uint64(len(s.Signers)/2 + 1); number >= limit {
+		for block := range s.Recents {
+			if block <= number-limit {
+				delete(s.Recents, block)
+			}
+		}
+	}
+}
+
 func (s *Archive) signers() []common.Address {
 	signers := make([]common.Address, 0, len(s.Signers))
 	for signer := range s.Signers {
@@ -258,6 +391,9 @@ func (s *Archive) ableSigners(number uint64) []common.Address {
 
 	signers := make([]common.Address, 0, len(s.Signers))
 	for signer := range s.Signers {
+		if _, jailed := s.Jailed[signer]; jailed {
+			continue
+		}
 		isRecent := false
 		for _, recent := range recents {
 			if recent == signer {
@@ -279,20 +415,30 @@ func (s *Archive) ableSigners(number uint64) []common.Address {
 	return signers
 }
 
-func (s *Archive) inturn(number uint64, signer common.Address, hash common.Hash) bool {
+func (s *Archive) inturnSigner(number uint64, hash common.Hash) (common.Address, bool) {
+	signers := s.ableSigners(number)
+	if len(signers) == 0 {
+		return common.Address{}, false
+	}
+	if s.config.IsHashTurn(new(big.Int).SetUint64(number)) {
+		return signers[turnIndex(number, hash, len(signers))], true
+	}
 	var lowHash int64
 	b_buf := bytes.NewBuffer(hash[len(hash)-8:])
 	binary.Read(b_buf, binary.BigEndian, &lowHash)
 
-	signers, offset := s.ableSigners(number), 0
-	sigLen := len(signers)
+	return signers[rand.New(rand.NewSource(lowHash)).Intn(len(signers))], true
+}
 
-	rand.Seed(lowHash)
- 	curTurn := rand.Intn(sigLen)
+func turnIndex(number uint64, hash common.Hash, count int) int {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
 
-	for offset < sigLen && signers[offset] != signer {
-		offset++
-	}
+	seed := new(big.Int).SetBytes(crypto.Keccak256(hash[:], enc[:]))
+	return int(seed.Mod(seed, big.NewInt(int64(count))).Int64())
+}
 
-	return (curTurn == offset)
+func (s *Archive) inturn(number uint64, signer common.Address, hash common.Hash) bool {
+	expected, ok := s.inturnSigner(number, hash)
+	return ok && expected == signer
 }
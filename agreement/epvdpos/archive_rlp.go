@@ -0,0 +1,171 @@
+package epvdpos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/process"
+)
+
+const archiveVersion = byte(0x01)
+
+var (
+	archivePrefix = []byte("epvdpos-")
+
+	errUnknownArchiveVersion = errors.New("unknown voting archive version")
+)
+
+type recentRLP struct {
+	Block  uint64
+	Signer common.Address
+}
+
+type tallyRLP struct {
+	Address   common.Address
+	Authorize bool
+	Votes     uint64
+}
+
+type statsRLP struct {
+	Signer       common.Address
+	Produced     uint64
+	MissedInturn uint64
+	OutOfTurn    uint64
+}
+
+type counterRLP struct {
+	Signer common.Address
+	Value  uint64
+}
+
+type archiveRLP struct {
+	Number  uint64
+	Hash    common.Hash
+	Signers []common.Address
+	Recents []recentRLP
+	Votes   []*Vote
+	Tally   []tallyRLP
+	Stats   []statsRLP
+	Misses  []counterRLP
+	Jailed  []counterRLP
+}
+
+func archiveKey(hash common.Hash) []byte {
+	return append(append([]byte{}, archivePrefix...), hash[:]...)
+}
+
+func isArchiveKey(key []byte) bool {
+	return len(key) == len(archivePrefix)+common.HashLength && bytes.HasPrefix(key, archivePrefix)
+}
+
+func sortedAddresses(addresses []common.Address) []common.Address {
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	return addresses
+}
+
+func sortedCounters(counters map[common.Address]uint64) []counterRLP {
+	addresses := make([]common.Address, 0, len(counters))
+	for address := range counters {
+		addresses = append(addresses, address)
+	}
+	enc := make([]counterRLP, 0, len(addresses))
+	for _, address := range sortedAddresses(addresses) {
+		enc = append(enc, counterRLP{address, counters[address]})
+	}
+	return enc
+}
+
+func encodeArchive(s *Archive) ([]byte, error) {
+	enc := &archiveRLP{
+		Number:  s.Number,
+		Hash:    s.Hash,
+		Signers: s.signers(),
+		Votes:   s.Votes,
+		Misses:  sortedCounters(s.Misses),
+		Jailed:  sortedCounters(s.Jailed),
+	}
+	blocks := make([]uint64, 0, len(s.Recents))
+	for block := range s.Recents {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	for _, block := range blocks {
+		enc.Recents = append(enc.Recents, recentRLP{block, s.Recents[block]})
+	}
+	addresses := make([]common.Address, 0, len(s.Tally))
+	for address := range s.Tally {
+		addresses = append(addresses, address)
+	}
+	for _, address := range sortedAddresses(addresses) {
+		tally := s.Tally[address]
+		enc.Tally = append(enc.Tally, tallyRLP{address, tally.Authorize, uint64(tally.Votes)})
+	}
+	addresses = make([]common.Address, 0, len(s.Stats))
+	for address := range s.Stats {
+		addresses = append(addresses, address)
+	}
+	for _, address := range sortedAddresses(addresses) {
+		stats := s.Stats[address]
+		enc.Stats = append(enc.Stats, statsRLP{address, stats.Produced, stats.MissedInturn, stats.OutOfTurn})
+	}
+	blob, err := rlp.EncodeToBytes(enc)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{archiveVersion}, blob...), nil
+}
+
+func decodeArchive(blob []byte) (*Archive, bool, error) {
+	if len(blob) == 0 {
+		return nil, false, errUnknownArchiveVersion
+	}
+	archive := &Archive{
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Tally:   make(map[common.Address]Tally),
+		Stats:   make(map[common.Address]*SignerStats),
+		Misses:  make(map[common.Address]uint64),
+		Jailed:  make(map[common.Address]uint64),
+	}
+	switch blob[0] {
+	case '{':
+		if err := json.Unmarshal(blob, archive); err != nil {
+			return nil, false, err
+		}
+		return archive, true, nil
+
+	case archiveVersion:
+		var enc archiveRLP
+		if err := rlp.DecodeBytes(blob[1:], &enc); err != nil {
+			return nil, false, err
+		}
+		archive.Number, archive.Hash, archive.Votes = enc.Number, enc.Hash, enc.Votes
+		for _, signer := range enc.Signers {
+			archive.Signers[signer] = struct{}{}
+		}
+		for _, recent := range enc.Recents {
+			archive.Recents[recent.Block] = recent.Signer
+		}
+		for _, tally := range enc.Tally {
+			archive.Tally[tally.Address] = Tally{Authorize: tally.Authorize, Votes: int(tally.Votes)}
+		}
+		for _, stats := range enc.Stats {
+			archive.Stats[stats.Signer] = &SignerStats{stats.Produced, stats.MissedInturn, stats.OutOfTurn}
+		}
+		for _, misses := range enc.Misses {
+			archive.Misses[misses.Signer] = misses.Value
+		}
+		for _, jailed := range enc.Jailed {
+			archive.Jailed[jailed.Signer] = jailed.Value
+		}
+		return archive, false, nil
+
+	default:
+		return nil, false, errUnknownArchiveVersion
+	}
+}
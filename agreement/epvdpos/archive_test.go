@@ -0,0 +1,110 @@
+package epvdpos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/content"
+)
+
+var (
+	zeroHash    = common.Hash{}
+	oneHash     = common.HexToHash("0x01")
+	mainnetHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	fullHash    = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+// Tests that the in-turn index is derived from the parent hash and block
+// number only, and stays fixed across releases.
+func TestTurnIndex(t *testing.T) {
+	tests := []struct {
+		number uint64
+		hash   common.Hash
+		count  int
+		index  int
+	}{
+		{1, zeroHash, 1, 0},
+		{1, zeroHash, 3, 1},
+		{1, zeroHash, 21, 7},
+		{2, zeroHash, 3, 2},
+		{2, zeroHash, 21, 2},
+		{30000, zeroHash, 21, 11},
+		{1, oneHash, 3, 0},
+		{1, oneHash, 21, 9},
+		{2, oneHash, 21, 11},
+		{30000, oneHash, 21, 17},
+		{1, mainnetHash, 21, 15},
+		{2, mainnetHash, 21, 8},
+		{30000, mainnetHash, 3, 2},
+		{30000, mainnetHash, 21, 20},
+		{1, fullHash, 21, 3},
+		{2, fullHash, 3, 0},
+		{2, fullHash, 21, 15},
+		{30000, fullHash, 3, 1},
+		{30000, fullHash, 21, 16},
+	}
+	for i, tt := range tests {
+		if index := turnIndex(tt.number, tt.hash, tt.count); index != tt.index {
+			t.Errorf("test %d: index mismatch: have %d, want %d", i, index, tt.index)
+		}
+	}
+}
+
+// Tests that the in-turn signer is picked among the signers that are neither
+// recent nor jailed, both before and after the hash based selection fork.
+func TestInturnSigner(t *testing.T) {
+	signers := []common.Address{
+		common.HexToAddress("0x01"),
+		common.HexToAddress("0x02"),
+		common.HexToAddress("0x03"),
+		common.HexToAddress("0x04"),
+		common.HexToAddress("0x05"),
+	}
+	tests := []struct {
+		hashTurn int64
+		signers  []common.Address
+		recents  map[uint64]common.Address
+		jailed   []common.Address
+		number   uint64
+		hash     common.Hash
+		inturn   common.Address
+		ok       bool
+	}{
+		// Hash based selection skipping a recent signer
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 10, oneHash, signers[2], true},
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 11, oneHash, signers[0], true},
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 10, mainnetHash, signers[2], true},
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 11, mainnetHash, signers[0], true},
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 10, fullHash, signers[2], true},
+		{0, signers, map[uint64]common.Address{9: signers[1]}, nil, 11, fullHash, signers[2], true},
+
+		// Hash based selection skipping a jailed signer
+		{0, signers, nil, []common.Address{signers[2]}, 10, oneHash, signers[1], true},
+		{0, signers, nil, []common.Address{signers[2]}, 10, mainnetHash, signers[1], true},
+
+		// Legacy selection seeded by the parent hash, before the fork
+		{1000, signers, map[uint64]common.Address{9: signers[1]}, nil, 10, oneHash, signers[2], true},
+		{1000, signers, map[uint64]common.Address{9: signers[1]}, nil, 11, oneHash, signers[2], true},
+		{1000, signers, map[uint64]common.Address{9: signers[1]}, nil, 10, mainnetHash, signers[4], true},
+		{1000, signers, map[uint64]common.Address{9: signers[1]}, nil, 11, fullHash, signers[4], true},
+
+		// No signer able to seal
+		{0, nil, nil, nil, 10, zeroHash, common.Address{}, false},
+	}
+	for i, tt := range tests {
+		config := &params.DPosConfig{Epoch: 30000, HashTurnBlock: big.NewInt(tt.hashTurn)}
+
+		archive := newArchive(config, nil, 0, common.Hash{}, tt.signers)
+		for number, signer := range tt.recents {
+			archive.Recents[number] = signer
+		}
+		for _, signer := range tt.jailed {
+			archive.Jailed[signer] = 0
+		}
+		inturn, ok := archive.inturnSigner(tt.number, tt.hash)
+		if inturn != tt.inturn || ok != tt.ok {
+			t.Errorf("test %d: in-turn signer mismatch: have %x (%v), want %x (%v)", i, inturn, ok, tt.inturn, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package epvdpos
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/content"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	defaultMaxSigners = 21
+
+	opRegister    = byte(0x01)
+	opDelegate    = byte(0x02)
+	opUndelegate  = byte(0x03)
+	opBeneficiary = byte(0x04)
+	opClaim       = byte(0x05)
+	opEvidence    = byte(0x06)
+)
+
+var RegistryAddress = common.HexToAddress("0x000000000000000000000000000000000000d905")
+
+var (
+	candidateCountKey = crypto.Keccak256Hash([]byte("epvdpos-candidates"))
+	electedCountKey   = crypto.Keccak256Hash([]byte("epvdpos-elected"))
+)
+
+type Candidate struct {
+	Address common.Address `json:"address"`
+	Stake   *big.Int       `json:"stake"`
+	Slashed bool           `json:"slashed"`
+}
+
+func indexKey(base common.Hash, index uint64) common.Hash {
+	return crypto.Keccak256Hash(base[:], new(big.Int).SetUint64(index).Bytes())
+}
+
+func candidateKey(candidate common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-candidate"), candidate[:])
+}
+
+func stakeKey(candidate common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-stake"), candidate[:])
+}
+
+func delegationKey(delegator, candidate common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-delegation"), delegator[:], candidate[:])
+}
+
+func getBig(statedb *state.StateDB, key common.Hash) *big.Int {
+	return statedb.GetState(RegistryAddress, key).Big()
+}
+
+func setBig(statedb *state.StateDB, key common.Hash, value *big.Int) {
+	statedb.SetState(RegistryAddress, key, common.BigToHash(value))
+}
+
+func touchRegistry(statedb *state.StateDB) {
+	if statedb.GetNonce(RegistryAddress) == 0 {
+		statedb.SetNonce(RegistryAddress, 1)
+	}
+}
+
+func isCandidate(statedb *state.StateDB, candidate common.Address) bool {
+	return statedb.GetState(RegistryAddress, candidateKey(candidate)) != (common.Hash{})
+}
+
+func register(statedb *state.StateDB, candidate common.Address) {
+	if isCandidate(statedb, candidate) {
+		return
+	}
+	touchRegistry(statedb)
+
+	count := getBig(statedb, candidateCountKey).Uint64()
+	statedb.SetState(RegistryAddress, indexKey(candidateCountKey, count), candidate.Hash())
+	setBig(statedb, candidateKey(candidate), new(big.Int).SetUint64(count+1))
+	setBig(statedb, candidateCountKey, new(big.Int).SetUint64(count+1))
+}
+
+func delegate(statedb *state.StateDB, delegator, candidate common.Address, amount *big.Int) bool {
+	if !isCandidate(statedb, candidate) {
+		return false
+	}
+	touchRegistry(statedb)
+	settle(statedb, delegator, candidate)
+
+	key := delegationKey(delegator, candidate)
+	setBig(statedb, key, new(big.Int).Add(getBig(statedb, key), amount))
+	setBig(statedb, stakeKey(candidate), new(big.Int).Add(getBig(statedb, stakeKey(candidate)), amount))
+
+	resetDebt(statedb, delegator, candidate)
+	return true
+}
+
+func undelegate(statedb *state.StateDB, delegator, candidate common.Address, amount *big.Int) bool {
+	key := delegationKey(delegator, candidate)
+	delegated := getBig(statedb, key)
+	if amount.Sign() <= 0 || delegated.Cmp(amount) < 0 || statedb.GetBalance(RegistryAddress).Cmp(amount) < 0 {
+		return false
+	}
+	settle(statedb, delegator, candidate)
+
+	setBig(statedb, key, new(big.Int).Sub(delegated, amount))
+	setBig(statedb, stakeKey(candidate), new(big.Int).Sub(getBig(statedb, stakeKey(candidate)), amount))
+	resetDebt(statedb, delegator, candidate)
+
+	statedb.SubBalance(RegistryAddress, amount)
+	statedb.AddBalance(delegator, amount)
+	return true
+}
+
+func applyRegistryTx(statedb *state.StateDB, from common.Address, tx *types.Transaction, sigcache *lru.ARCCache) {
+	var (
+		data   = tx.Data()
+		value  = tx.Value()
+		staked bool
+	)
+	if len(data) > 0 {
+		switch data[0] {
+		case opRegister:
+			register(statedb, from)
+			staked = value.Sign() == 0 || delegate(statedb, from, from, value)
+
+		case opDelegate:
+			if len(data) >= 1+common.AddressLength {
+				staked = delegate(statedb, from, common.BytesToAddress(data[1:1+common.AddressLength]), value)
+			}
+		case opUndelegate:
+			if len(data) >= 1+common.AddressLength+common.HashLength {
+				candidate := common.BytesToAddress(data[1 : 1+common.AddressLength])
+				amount := new(big.Int).SetBytes(data[1+common.AddressLength : 1+common.AddressLength+common.HashLength])
+				undelegate(statedb, from, candidate, amount)
+			}
+		case opBeneficiary:
+			if len(data) >= 1+common.AddressLength {
+				setBeneficiary(statedb, from, common.BytesToAddress(data[1:1+common.AddressLength]))
+			}
+		case opClaim:
+			if len(data) >= 1+common.AddressLength {
+				candidate := common.BytesToAddress(data[1 : 1+common.AddressLength])
+				settle(statedb, from, candidate)
+				resetDebt(statedb, from, candidate)
+			}
+		case opEvidence:
+			if evidence, err := decodeEvidence(data[1:], sigcache); err == nil {
+				slash(statedb, evidence)
+			}
+		}
+	}
+	if !staked && value.Sign() > 0 {
+		statedb.SubBalance(RegistryAddress, value)
+		statedb.AddBalance(from, value)
+	}
+}
+
+func applyRegistry(config *params.ChainConfig, header *types.Header, statedb *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt, sigcache *lru.ARCCache) error {
+	signer := types.MakeSigner(config, header.Number)
+	for i, tx := range txs {
+		if tx.To() == nil || *tx.To() != RegistryAddress {
+			continue
+		}
+		if i < len(receipts) && receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		applyRegistryTx(statedb, from, tx, sigcache)
+	}
+	return nil
+}
+
+func Candidates(statedb *state.StateDB) []Candidate {
+	count := getBig(statedb, candidateCountKey).Uint64()
+
+	candidates := make([]Candidate, 0, count)
+	for i := uint64(0); i < count; i++ {
+		address := common.BytesToAddress(statedb.GetState(RegistryAddress, indexKey(candidateCountKey, i)).Bytes())
+		candidates = append(candidates, Candidate{
+			Address: address,
+			Stake:   getBig(statedb, stakeKey(address)),
+			Slashed: IsSlashed(statedb, address),
+		})
+	}
+	return candidates
+}
+
+func elect(statedb *state.StateDB, maxSigners uint64) []common.Address {
+	if maxSigners == 0 {
+		maxSigners = defaultMaxSigners
+	}
+	candidates := Candidates(statedb)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if cmp := candidates[i].Stake.Cmp(candidates[j].Stake); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(candidates[i].Address[:], candidates[j].Address[:]) < 0
+	})
+	elected := make([]common.Address, 0, maxSigners)
+	for _, candidate := range candidates {
+		if uint64(len(elected)) == maxSigners || candidate.Stake.Sign() <= 0 {
+			break
+		}
+		if candidate.Slashed {
+			continue
+		}
+		elected = append(elected, candidate.Address)
+	}
+	sort.Slice(elected, func(i, j int) bool {
+		return bytes.Compare(elected[i][:], elected[j][:]) < 0
+	})
+	return elected
+}
+
+func storeElected(statedb *state.StateDB, elected []common.Address) {
+	touchRegistry(statedb)
+
+	for i, signer := range elected {
+		statedb.SetState(RegistryAddress, indexKey(electedCountKey, uint64(i)), signer.Hash())
+	}
+	setBig(statedb, electedCountKey, big.NewInt(int64(len(elected))))
+}
+
+func electedSigners(statedb *state.StateDB) []common.Address {
+	count := getBig(statedb, electedCountKey).Uint64()
+
+	elected := make([]common.Address, 0, count)
+	for i := uint64(0); i < count; i++ {
+		elected = append(elected, common.BytesToAddress(statedb.GetState(RegistryAddress, indexKey(electedCountKey, i)).Bytes()))
+	}
+	return elected
+}
+
+// isRegistry reports whether registry transactions, and so double signing
+// evidence and rewards, take effect in the block. Such blocks name their
+// sealer in the mix digest.
+func isRegistry(config *params.DPosConfig, number *big.Int) bool {
+	return config.IsElection(number) || config.IsReward(number)
+}
+
+// checkpointSigners returns the signers a checkpoint block lists, leaving out
+// the ones slashed up to and including the block.
+func checkpointSigners(arch *Archive, statedb *state.StateDB) []common.Address {
+	signers := electedSigners(statedb)
+	if len(signers) == 0 {
+		signers = arch.signers()
+	}
+	unslashed := make([]common.Address, 0, len(signers))
+	for _, signer := range signers {
+		if !IsSlashed(statedb, signer) {
+			unslashed = append(unslashed, signer)
+		}
+	}
+	return unslashed
+}
+
+// slashedSigners returns the signers of the archive that are slashed in
+// statedb, which a non-checkpoint block lists so the archive drops them.
+func slashedSigners(arch *Archive, statedb *state.StateDB) []common.Address {
+	var slashed []common.Address
+	for _, signer := range arch.signers() {
+		if IsSlashed(statedb, signer) {
+			slashed = append(slashed, signer)
+		}
+	}
+	return slashed
+}
@@ -13,14 +13,9 @@ type EAPI struct {
 }
 
 func (e *EAPI) GetArchive(number *rpc.BlockNumber) (*Archive, error) {
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = e.chain.CurrentHeader()
-	} else {
-		header = e.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	if header == nil {
-		return nil, errUnknownBlock
+	header, err := e.header(number)
+	if err != nil {
+		return nil, err
 	}
 	return e.dpos.archive(e.chain, header.Number.Uint64(), header.Hash(), nil)
 }
@@ -52,14 +47,9 @@ func (e *EAPI) GetArchiveAtHash(hash common.Hash) (*Archive, error) {
 }
 
 func (e *EAPI) GetSigners(number *rpc.BlockNumber) ([]common.Address, error) {
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = e.chain.CurrentHeader()
-	} else {
-		header = e.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	if header == nil {
-		return nil, errUnknownBlock
+	header, err := e.header(number)
+	if err != nil {
+		return nil, err
 	}
 	archive, err := e.dpos.archive(e.chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
@@ -86,3 +76,117 @@ func (e *EAPI) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	}
 	return archive.signers(), nil
 }
+
+func (e *EAPI) GetCandidates(number *rpc.BlockNumber) ([]Candidate, error) {
+	header, err := e.header(number)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := headerState(e.chain, header)
+	if err != nil {
+		return nil, err
+	}
+	return Candidates(statedb), nil
+}
+
+func (e *EAPI) GetInturnSigner(number *rpc.BlockNumber) (common.Address, error) {
+	var parent *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		parent = e.chain.CurrentHeader()
+	} else if *number < 0 {
+		return common.Address{}, errUnsupportedTag
+	} else if number.Int64() > 0 {
+		parent = e.chain.GetHeaderByNumber(uint64(number.Int64() - 1))
+	}
+	if parent == nil {
+		return common.Address{}, errUnknownBlock
+	}
+	archive, err := e.dpos.archive(e.chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer, ok := archive.inturnSigner(parent.Number.Uint64()+1, parent.Hash())
+	if !ok {
+		return common.Address{}, errUnauthorized
+	}
+	return signer, nil
+}
+
+func (e *EAPI) GetEvidence() ([]*Evidence, error) {
+	return e.dpos.Evidence()
+}
+
+type StatsRange struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+}
+
+type SignerActivity struct {
+	SignerStats
+	Jailed bool `json:"jailed"`
+}
+
+// header resolves a block number of the API to a header. The finalized tag
+// is rejected, as the engine does not track finality.
+func (e *EAPI) header(number *rpc.BlockNumber) (*types.Header, error) {
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		return e.chain.CurrentHeader(), nil
+	}
+	if *number < 0 {
+		return nil, errUnsupportedTag
+	}
+	header := e.chain.GetHeaderByNumber(uint64(number.Int64()))
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+func (e *EAPI) GetSignerStats(span StatsRange) (map[common.Address]*SignerActivity, error) {
+	last, err := e.header(span.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.dpos.archive(e.chain, last.Number.Uint64(), last.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	from := newArchive(e.dpos.config, e.dpos.signatures, 0, common.Hash{}, nil)
+	if span.FromBlock != nil && *span.FromBlock == rpc.FinalizedBlockNumber {
+		return nil, errUnsupportedTag
+	}
+	if span.FromBlock != nil && span.FromBlock.Int64() > 0 {
+		first, err := e.header(span.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		if first.Number.Uint64() > last.Number.Uint64() {
+			return nil, errUnknownBlock
+		}
+		parent := e.chain.GetHeader(first.ParentHash, first.Number.Uint64()-1)
+		if parent == nil {
+			return nil, errUnknownBlock
+		}
+		if from, err = e.dpos.archive(e.chain, parent.Number.Uint64(), parent.Hash(), nil); err != nil {
+			return nil, err
+		}
+	}
+	activity := make(map[common.Address]*SignerActivity)
+	for signer, stats := range to.Stats {
+		base, ok := from.Stats[signer]
+		if !ok {
+			base = new(SignerStats)
+		}
+
+		_, jailed := to.Jailed[signer]
+		activity[signer] = &SignerActivity{
+			SignerStats: SignerStats{
+				Produced:     stats.Produced - base.Produced,
+				MissedInturn: stats.MissedInturn - base.MissedInturn,
+				OutOfTurn:    stats.OutOfTurn - base.OutOfTurn,
+			},
+			Jailed: jailed,
+		}
+	}
+	return activity, nil
+}
@@ -36,7 +36,7 @@ const (
 )
 
 var (
-	epochLength = uint64(30000)
+	epochLength = uint64(params.DefaultDPosEpoch)
 	blockPeriod = uint64(15)
 
 	extraVanity = 32
@@ -53,6 +53,7 @@ var (
 
 var (
 	errUnknownBlock = errors.New("unknown block")
+	errUnsupportedTag = errors.New("block tag not supported")
 	errInvalidCheckpointBeneficiary = errors.New("beneficiary in checkpoint block non-zero")
 	errInvalidVote = errors.New("vote nonce not 0x00..0 or 0xff..f")
 	errInvalidCheckpointVote = errors.New("vote nonce in checkpoint block non-zero")
@@ -60,13 +61,18 @@ var (
 	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
 	errExtraSigners = errors.New("non-checkpoint block contains extra signer list")
 	errInvalidCheckpointSigners = errors.New("invalid signer list on checkpoint block")
+	errInvalidSlashedSigners = errors.New("invalid slashed signer list")
 	errInvalidMixDigest = errors.New("non-zero mix digest")
+	errInvalidSealer = errors.New("mix digest does not name the block signer")
 	errInvalidUncleHash = errors.New("non empty uncle hash")
 	errInvalidDifficulty = errors.New("invalid difficulty")
 	ErrInvalidTimestamp = errors.New("invalid timestamp")
 	errInvalidVotingChain = errors.New("invalid voting chain")
 	errUnauthorized = errors.New("unauthorized")
 	errWaitTransactions = errors.New("waiting for transactions")
+	errMissingState = errors.New("parent state not available")
+	errInvalidEvidence = errors.New("invalid double signing evidence")
+	errSlashedSigner = errors.New("signer slashed for double signing")
 )
 
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
@@ -74,7 +80,7 @@ type SignerFn func(accounts.Account, []byte) ([]byte, error)
 func sigHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewKeccak256()
 
-	rlp.Encode(hasher, []interface{}{
+	fields := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -90,11 +96,21 @@ func sigHash(header *types.Header) (hash common.Hash) {
 		header.Extra[:len(header.Extra)-65],
 		header.MixDigest,
 		header.Nonce,
-	})
+	}
+	if header.BaseFee != nil {
+		fields = append(fields, header.BaseFee)
+	}
+	rlp.Encode(hasher, fields)
 	hasher.Sum(hash[:0])
 	return hash
 }
 
+// SealHash returns the hash of header the block signer signs, which excludes
+// the signature itself.
+func SealHash(header *types.Header) common.Hash {
+	return sigHash(header)
+}
+
 func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
 	hash := header.Hash()
 	if address, known := sigcache.Get(hash); known {
@@ -122,12 +138,17 @@ type DPos struct {
 
 	recents    *lru.ARCCache
 	signatures *lru.ARCCache
+	seals      *lru.ARCCache
+
+	proposals  map[common.Address]bool
+	protection *SlashingProtection
 
-	proposals map[common.Address]bool
+	signer   common.Address
+	signFn   SignerFn
+	headerFn HeaderSignerFn
+	lock     sync.RWMutex
 
-	signer common.Address
-	signFn SignerFn
-	lock   sync.RWMutex
+	evidenceLock sync.Mutex
 }
 
 func New(config *params.DPosConfig, db epvdb.Database) *DPos {
@@ -135,15 +156,20 @@ func New(config *params.DPosConfig, db epvdb.Database) *DPos {
 	if conf.Epoch == 0 {
 		conf.Epoch = epochLength
 	}
+	conf.Schedule = make([]params.DPosRule, len(config.Schedule))
+	copy(conf.Schedule, config.Schedule)
 	recents, _ := lru.NewARC(inmemoryArchives)
 	signatures, _ := lru.NewARC(inmemorySignatures)
+	seals, _ := lru.NewARC(inmemorySeals)
 
 	return &DPos{
 		config:     &conf,
 		db:         db,
 		recents:    recents,
 		signatures: signatures,
+		seals:      seals,
 		proposals:  make(map[common.Address]bool),
+		protection: NewSlashingProtection(db),
 	}
 }
 
@@ -182,7 +208,7 @@ func (c *DPos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 	if header.TimeMS.Cmp(big.NewInt(time.Now().Add(futureTime).UnixNano()/1000000)) > 0 {
 		return consensus.ErrFutureBlock
 	}
-	checkpoint := (number % c.config.Epoch) == 0
+	checkpoint := c.config.IsCheckpoint(number)
 	if checkpoint && header.Coinbase != (common.Address{}) {
 		return errInvalidCheckpointBeneficiary
 	}
@@ -199,13 +225,20 @@ func (c *DPos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 		return errMissingSignature
 	}
 	signersBytes := len(header.Extra) - extraVanity - extraSeal
-	if !checkpoint && signersBytes != 0 {
+	if !checkpoint && signersBytes != 0 && !isRegistry(c.config, header.Number) {
 		return errExtraSigners
 	}
+	if !checkpoint && signersBytes%common.AddressLength != 0 {
+		return errInvalidSlashedSigners
+	}
 	if checkpoint && signersBytes%common.AddressLength != 0 {
 		return errInvalidCheckpointSigners
 	}
-	if header.MixDigest != (common.Hash{}) {
+	if isRegistry(c.config, header.Number) {
+		if header.MixDigest != declaredSealer(header).Hash() || declaredSealer(header) == (common.Address{}) {
+			return errInvalidSealer
+		}
+	} else if header.MixDigest != (common.Hash{}) {
 		return errInvalidMixDigest
 	}
 	if header.UncleHash != uncleHash {
@@ -236,26 +269,131 @@ func (c *DPos) verifyCascadingFields(chain consensus.ChainReader, header *types.
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.TimeMS.Uint64()+c.config.Period > header.TimeMS.Uint64() {
+	if parent.TimeMS.Uint64()+c.config.At(number).Period > header.TimeMS.Uint64() {
 		return ErrInvalidTimestamp
 	}
+	if err := misc.VerifyBaseFee(chain.Config(), parent, header); err != nil {
+		return err
+	}
 	arch, err := c.archive(chain, number-1, header.ParentHash, parents)
 	if err != nil {
 		return err
 	}
-	if number%c.config.Epoch == 0 {
-		signers := make([]byte, len(arch.Signers)*common.AddressLength)
-		for i, signer := range arch.signers() {
-			copy(signers[i*common.AddressLength:], signer[:])
+	slashing := isRegistry(c.config, header.Number)
+	if !c.config.IsCheckpoint(number) && slashing {
+		// Signers slashed by the block are checked against its registry
+		// transactions in Finalize, only their order and membership here.
+		listed := headerSigners(header)
+		for i, signer := range listed {
+			if _, ok := arch.Signers[signer]; !ok || (i > 0 && bytes.Compare(listed[i-1][:], signer[:]) >= 0) {
+				return errInvalidSlashedSigners
+			}
 		}
-		extraSuffix := len(header.Extra) - extraSeal
-		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], signers) {
-			return errInvalidCheckpointSigners
+	}
+	if c.config.IsCheckpoint(number) {
+		if !c.config.IsElection(header.Number) {
+			// Signers slashed by the checkpoint block itself are left out of
+			// its list, which Finalize checks once the evidence is known.
+			verify := verifyCheckpointSigners
+			if slashing {
+				verify = verifyCheckpointSubset
+			}
+			if err := verify(header, arch.signers()); err != nil {
+				return err
+			}
+		} else {
+			// Headers are verified ahead of their parent state during batch
+			// imports and header only syncs. Without the state the elected
+			// signers are left to Finalize, which checks them when the block
+			// is processed, and archives adopting them are kept pending.
+			statedb, err := headerState(chain, parent)
+			if err != nil {
+				log.Trace("Deferring checkpoint signer check to finalization", "number", number, "err", err)
+			} else if err := verifyCheckpointSubset(header, checkpointSigners(arch, statedb)); err != nil {
+				return err
+			}
 		}
 	}
 	return c.verifySeal(chain, header, parents)
 }
 
+type stateReader interface {
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+func headerState(chain consensus.ChainReader, parent *types.Header) (*state.StateDB, error) {
+	reader, ok := chain.(stateReader)
+	if !ok {
+		return nil, errMissingState
+	}
+	return reader.StateAt(parent.Root)
+}
+
+func encodeSigners(signers []common.Address) []byte {
+	enc := make([]byte, len(signers)*common.AddressLength)
+	for i, signer := range signers {
+		copy(enc[i*common.AddressLength:], signer[:])
+	}
+	return enc
+}
+
+func headerSigners(header *types.Header) []common.Address {
+	list := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+
+	signers := make([]common.Address, 0, len(list)/common.AddressLength)
+	for i := 0; i+common.AddressLength <= len(list); i += common.AddressLength {
+		signers = append(signers, common.BytesToAddress(list[i:i+common.AddressLength]))
+	}
+	return signers
+}
+
+func verifyCheckpointSigners(header *types.Header, signers []common.Address) error {
+	extraSuffix := len(header.Extra) - extraSeal
+	if !bytes.Equal(header.Extra[extraVanity:extraSuffix], encodeSigners(signers)) {
+		return errInvalidCheckpointSigners
+	}
+	return nil
+}
+
+// verifyCheckpointSubset checks that the checkpoint signer list only holds
+// signers, in their order, for checkpoints that may still drop slashed ones.
+func verifyCheckpointSubset(header *types.Header, signers []common.Address) error {
+	i := 0
+	for _, listed := range headerSigners(header) {
+		for i < len(signers) && signers[i] != listed {
+			i++
+		}
+		if i == len(signers) {
+			return errInvalidCheckpointSigners
+		}
+		i++
+	}
+	return nil
+}
+
+// sealed reports whether the header carries a signature, as opposed to one
+// still being assembled locally.
+func sealed(header *types.Header) bool {
+	return len(header.Extra) >= extraSeal && !bytes.Equal(header.Extra[len(header.Extra)-extraSeal:], make([]byte, extraSeal))
+}
+
+// declareSigners fills the signer list of a locally assembled header, or
+// checks it against signers once the header is sealed.
+func declareSigners(header *types.Header, signers []common.Address, mismatch error) error {
+	list := encodeSigners(signers)
+	if !sealed(header) {
+		extra := make([]byte, 0, extraVanity+len(list)+extraSeal)
+		extra = append(extra, header.Extra[:extraVanity]...)
+		extra = append(extra, list...)
+		header.Extra = append(extra, make([]byte, extraSeal)...)
+		return nil
+	}
+	if !bytes.Equal(header.Extra[extraVanity:len(header.Extra)-extraSeal], list) {
+		return mismatch
+	}
+	return nil
+}
+
 func (c *DPos) archive(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Archive, error) {
 	var (
 		headers []*types.Header
@@ -263,8 +401,13 @@ func (c *DPos) archive(chain consensus.ChainReader, number uint64, hash common.H
 	)
 	for arch == nil {
 		if s, ok := c.recents.Get(hash); ok {
-			arch = s.(*Archive)
-			break
+			if s := s.(*Archive); s.pending == nil || !c.verifiable(chain, s.pending) {
+				arch = s
+				break
+			}
+			// The state an adopted signer list was not checked against has
+			// arrived since, rebuild the archive from before the checkpoint.
+			c.recents.Remove(hash)
 		}
 		if number%checkpointInterval == 0 {
 			if s, err := loadArchive(c.config, c.signatures, c.db, hash); err == nil {
@@ -308,13 +451,13 @@ func (c *DPos) archive(chain consensus.ChainReader, number uint64, hash common.H
 	for i := 0; i < len(headers)/2; i++ {
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
-	arch, err := arch.apply(headers)
+	arch, err := c.applyElected(chain, arch, headers)
 	if err != nil {
 		return nil, err
 	}
 	c.recents.Add(arch.Hash, arch)
 
-	if arch.Number%checkpointInterval == 0 && len(headers) > 0 {
+	if arch.Number%checkpointInterval == 0 && len(headers) > 0 && arch.pending == nil {
 		if err = arch.store(c.db); err != nil {
 			return nil, err
 		}
@@ -323,6 +466,63 @@ func (c *DPos) archive(chain consensus.ChainReader, number uint64, hash common.H
 	return arch, err
 }
 
+// applyElected applies headers to arch, checking the signer list of every
+// election checkpoint against its parent state. Lists adopted without the
+// state mark the resulting archive as pending.
+func (c *DPos) applyElected(chain consensus.ChainReader, arch *Archive, headers []*types.Header) (*Archive, error) {
+	for len(headers) > 0 {
+		i := 0
+		for ; i < len(headers); i++ {
+			if c.config.IsCheckpoint(headers[i].Number.Uint64()) && c.config.IsElection(headers[i].Number) {
+				break
+			}
+		}
+		next, err := arch.apply(headers[:i])
+		if err != nil {
+			return nil, err
+		}
+		arch = next
+		if i == len(headers) {
+			break
+		}
+		checkpoint := headers[i]
+		var parent *types.Header
+		if i > 0 {
+			parent = headers[i-1]
+		} else {
+			parent = chain.GetHeader(arch.Hash, arch.Number)
+		}
+		verified := false
+		if parent != nil {
+			if statedb, err := headerState(chain, parent); err == nil {
+				if err := verifyCheckpointSubset(checkpoint, checkpointSigners(arch, statedb)); err != nil {
+					return nil, err
+				}
+				verified = true
+			}
+		}
+		if arch, err = arch.apply(headers[i : i+1]); err != nil {
+			return nil, err
+		}
+		if !verified && arch.pending == nil {
+			arch.pending = checkpoint
+		}
+		headers = headers[i+1:]
+	}
+	return arch, nil
+}
+
+// verifiable reports whether the parent state of an election checkpoint is
+// available to check its signer list against.
+func (c *DPos) verifiable(chain consensus.ChainReader, checkpoint *types.Header) bool {
+	parent := chain.GetHeader(checkpoint.ParentHash, checkpoint.Number.Uint64()-1)
+	if parent == nil {
+		return false
+	}
+	_, err := headerState(chain, parent)
+	return err == nil
+}
+
 func (c *DPos) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
 	if len(block.Uncles()) > 0 {
 		return errors.New("uncles not allowed")
@@ -348,9 +548,14 @@ func (c *DPos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	if err != nil {
 		return err
 	}
+	if isRegistry(c.config, header.Number) && declaredSealer(header) != signer {
+		return errInvalidSealer
+	}
 	if _, ok := arch.Signers[signer]; !ok {
 		return errUnauthorized
 	}
+	c.checkEquivocation(signer, header)
+
 	for seen, recent := range arch.Recents {
 		if recent == signer {
 			if limit := uint64(len(arch.Signers)/2 + 1); seen > number-limit {
@@ -377,7 +582,7 @@ func (c *DPos) Prepare(chain consensus.ChainReader, header *types.Header) error
 	if err != nil {
 		return err
 	}
-	if number%c.config.Epoch != 0 {
+	if !c.config.IsCheckpoint(number) && !c.config.IsElection(header.Number) {
 		c.lock.RLock()
 
 		addresses := make([]common.Address, 0, len(c.proposals))
@@ -403,20 +608,33 @@ func (c *DPos) Prepare(chain consensus.ChainReader, header *types.Header) error
 	}
 	header.Extra = header.Extra[:extraVanity]
 
-	if number%c.config.Epoch == 0 {
-		for _, signer := range arch.signers() {
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if c.config.IsCheckpoint(number) {
+		signers := arch.signers()
+		if c.config.IsElection(header.Number) {
+			statedb, err := headerState(chain, parent)
+			if err != nil {
+				return err
+			}
+			signers = checkpointSigners(arch, statedb)
+		}
+		for _, signer := range signers {
 			header.Extra = append(header.Extra, signer[:]...)
 		}
 	}
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
 	header.MixDigest = common.Hash{}
-
-	parent := chain.GetHeader(header.ParentHash, number-1)
-	if parent == nil {
-		return consensus.ErrUnknownAncestor
+	if isRegistry(c.config, header.Number) {
+		c.lock.RLock()
+		header.MixDigest = c.signer.Hash()
+		c.lock.RUnlock()
 	}
-	header.TimeMS = new(big.Int).Add(parent.TimeMS, new(big.Int).SetUint64(c.config.Period))
+
+	header.TimeMS = new(big.Int).Add(parent.TimeMS, new(big.Int).SetUint64(c.config.At(number).Period))
 	if header.TimeMS.Int64() < time.Now().UnixNano()/1000000 {
 		header.TimeMS = big.NewInt(time.Now().UnixNano()/1000000)
 	}
@@ -424,18 +642,119 @@ func (c *DPos) Prepare(chain consensus.ChainReader, header *types.Header) error
 }
 
 func (c *DPos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	if err := c.finalizeElection(chain, header, state, txs, receipts); err != nil {
+		return nil, err
+	}
+	if c.config.IsReward(header.Number) {
+		signer, err := c.sealer(header)
+		if err != nil {
+			return nil, err
+		}
+		accumulateRewards(c.config, state, header, signer)
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
 
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
 
+func (c *DPos) finalizeElection(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	number := header.Number.Uint64()
+	if c.config.IsElection(header.Number) || c.config.IsReward(header.Number) {
+		signer, err := c.sealer(header)
+		if err != nil {
+			return err
+		}
+		if IsSlashed(statedb, signer) {
+			return errSlashedSigner
+		}
+		if err := applyRegistry(chain.Config(), header, statedb, txs, receipts, c.signatures); err != nil {
+			return err
+		}
+	}
+	if isRegistry(c.config, header.Number) {
+		arch, err := c.archive(chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return err
+		}
+		if c.config.IsCheckpoint(number) {
+			if err := declareSigners(header, checkpointSigners(arch, statedb), errInvalidCheckpointSigners); err != nil {
+				return err
+			}
+		} else if err := declareSigners(header, slashedSigners(arch, statedb), errInvalidSlashedSigners); err != nil {
+			return err
+		}
+	}
+	next := new(big.Int).Add(header.Number, big.NewInt(1))
+	if c.config.IsCheckpoint(next.Uint64()) && c.config.IsElection(next) {
+		storeElected(statedb, elect(statedb, c.config.At(next.Uint64()).MaxSigners))
+	}
+	return nil
+}
+
+// sealer returns the signer a block is attributed to, using header data only.
+// Registry blocks name it in their mix digest, which verifySeal checks against
+// the signature, so it is known before a local block is sealed.
+func (c *DPos) sealer(header *types.Header) (common.Address, error) {
+	if isRegistry(c.config, header.Number) {
+		if signer := declaredSealer(header); signer != (common.Address{}) {
+			return signer, nil
+		}
+		return common.Address{}, errInvalidSealer
+	}
+	return ecrecover(header, c.signatures)
+}
+
+func declaredSealer(header *types.Header) common.Address {
+	return common.BytesToAddress(header.MixDigest[common.HashLength-common.AddressLength:])
+}
+
+func (c *DPos) Signer() common.Address {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.signer
+}
+
+func (c *DPos) SignHash(hash common.Hash) ([]byte, error) {
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+
+	if signFn == nil {
+		return nil, errUnauthorized
+	}
+	return signFn(accounts.Account{Address: signer}, hash.Bytes())
+}
+
+func (c *DPos) Protection() *SlashingProtection {
+	return c.protection
+}
+
+func (c *DPos) Signers(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	arch, err := c.archive(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return arch.signers(), nil
+}
+
 func (c *DPos) Authorize(signer common.Address, signFn SignerFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	c.signer = signer
 	c.signFn = signFn
+	c.headerFn = nil
+}
+
+func (c *DPos) AuthorizeRemote(signer common.Address, remote *RemoteSigner) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.signer = signer
+	c.signFn = remote.SignHash
+	c.headerFn = remote.SignHeader
 }
 
 func (c *DPos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
@@ -445,13 +764,16 @@ func (c *DPos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 	if number == 0 {
 		return nil, errUnknownBlock
 	}
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
+	if c.config.At(number).Period == 0 && len(block.Transactions()) == 0 {
 		return nil, errWaitTransactions
 	}
 	c.lock.RLock()
-	signer, signFn := c.signer, c.signFn
+	signer, signFn, headerFn := c.signer, c.signFn, c.headerFn
 	c.lock.RUnlock()
 
+	if signFn == nil {
+		return nil, errUnauthorized
+	}
 	arch, err := c.archive(chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return nil, err
@@ -459,6 +781,9 @@ func (c *DPos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 	if _, authorized := arch.Signers[signer]; !authorized {
 		return nil, errUnauthorized
 	}
+	if isRegistry(c.config, header.Number) && declaredSealer(header) != signer {
+		return nil, errInvalidSealer
+	}
 	for seen, recent := range arch.Recents {
 		if recent == signer {
 			if limit := uint64(len(arch.Signers)/2 + 1); number < limit || seen > number-limit {
@@ -482,10 +807,25 @@ func (c *DPos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 		return nil, nil
 	case <-time.After(delay):
 	}
-	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	recorded, err := c.protection.CheckAndRecord(signer, header)
 	if err != nil {
 		return nil, err
 	}
+	var sighash []byte
+	if headerFn != nil {
+		sighash, err = headerFn(accounts.Account{Address: signer}, header)
+	} else {
+		sighash, err = signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	}
+	if err != nil {
+		log.Warn("Failed to sign block", "number", number, "signer", signer, "err", err)
+		if recorded {
+			if err := c.protection.Release(signer, header); err != nil {
+				log.Error("Failed to release slashing protection record", "number", number, "signer", signer, "err", err)
+			}
+		}
+		return nil, err
+	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 
 	return block.WithSeal(header), nil
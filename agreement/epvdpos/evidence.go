@@ -0,0 +1,197 @@
+package epvdpos
+
+import (
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/process"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const inmemorySeals = 4096
+
+var evidenceDBKey = []byte("epvdpos-evidence")
+
+type sealKey struct {
+	signer common.Address
+	number uint64
+}
+
+type Evidence struct {
+	Signer  common.Address `json:"signer"`
+	Number  uint64         `json:"number"`
+	First   *types.Header  `json:"first"`
+	Second  *types.Header  `json:"second"`
+	Payload hexutil.Bytes  `json:"payload"`
+}
+
+type evidenceRLP struct {
+	First  *types.Header
+	Second *types.Header
+}
+
+func newEvidence(first, second *types.Header, sigcache *lru.ARCCache) (*Evidence, error) {
+	if first.Number == nil || second.Number == nil || first.Number.Cmp(second.Number) != 0 {
+		return nil, errInvalidEvidence
+	}
+	signer, err := ecrecover(first, sigcache)
+	if err != nil {
+		return nil, err
+	}
+	other, err := ecrecover(second, sigcache)
+	if err != nil {
+		return nil, err
+	}
+	if signer != other {
+		return nil, errInvalidEvidence
+	}
+	// Only headers differing in their signed content conflict, a signature is
+	// malleable and must not turn an honest block into evidence.
+	if !canonicalSeal(first) || !canonicalSeal(second) || sigHash(first) == sigHash(second) {
+		return nil, errInvalidEvidence
+	}
+	payload, err := rlp.EncodeToBytes(&evidenceRLP{first, second})
+	if err != nil {
+		return nil, err
+	}
+	return &Evidence{
+		Signer:  signer,
+		Number:  first.Number.Uint64(),
+		First:   first,
+		Second:  second,
+		Payload: append([]byte{opEvidence}, payload...),
+	}, nil
+}
+
+// canonicalSeal reports whether the seal of header is a low-s signature.
+func canonicalSeal(header *types.Header) bool {
+	sig := header.Extra[len(header.Extra)-extraSeal:]
+	return crypto.ValidateSignatureValues(sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), true)
+}
+
+func decodeEvidence(payload []byte, sigcache *lru.ARCCache) (*Evidence, error) {
+	var enc evidenceRLP
+	if err := rlp.DecodeBytes(payload, &enc); err != nil {
+		return nil, err
+	}
+	if enc.First == nil || enc.Second == nil {
+		return nil, errInvalidEvidence
+	}
+	return newEvidence(enc.First, enc.Second, sigcache)
+}
+
+func loadEvidence(db epvdb.Database, sigcache *lru.ARCCache) ([]*Evidence, error) {
+	blob, err := db.Get(evidenceDBKey)
+	if err != nil {
+		return nil, nil
+	}
+	var encs []evidenceRLP
+	if err := rlp.DecodeBytes(blob, &encs); err != nil {
+		return nil, err
+	}
+	evidence := make([]*Evidence, 0, len(encs))
+	for _, enc := range encs {
+		e, err := newEvidence(enc.First, enc.Second, sigcache)
+		if err == errInvalidEvidence {
+			log.Warn("Dropping invalid double signing evidence", "number", enc.First.Number)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		evidence = append(evidence, e)
+	}
+	return evidence, nil
+}
+
+func storeEvidence(db epvdb.Database, evidence []*Evidence) error {
+	encs := make([]evidenceRLP, len(evidence))
+	for i, e := range evidence {
+		encs[i] = evidenceRLP{e.First, e.Second}
+	}
+	blob, err := rlp.EncodeToBytes(encs)
+	if err != nil {
+		return err
+	}
+	return db.Put(evidenceDBKey, blob)
+}
+
+func (c *DPos) checkEquivocation(signer common.Address, header *types.Header) {
+	key := sealKey{signer, header.Number.Uint64()}
+	if seen, ok := c.seals.Get(key); ok {
+		first := seen.(*types.Header)
+		if sigHash(first) == sigHash(header) {
+			return
+		}
+		evidence, err := newEvidence(first, header, c.signatures)
+		if err != nil {
+			return
+		}
+		if err := c.recordEvidence(evidence); err != nil {
+			log.Error("Failed to store equivocation evidence", "signer", signer, "number", key.number, "err", err)
+		}
+		return
+	}
+	c.seals.Add(key, header)
+}
+
+func (c *DPos) recordEvidence(evidence *Evidence) error {
+	c.evidenceLock.Lock()
+	defer c.evidenceLock.Unlock()
+
+	known, err := loadEvidence(c.db, c.signatures)
+	if err != nil {
+		return err
+	}
+	for _, e := range known {
+		if e.Signer == evidence.Signer && e.Number == evidence.Number {
+			return nil
+		}
+	}
+	log.Warn("Detected double signing", "signer", evidence.Signer, "number", evidence.Number, "first", evidence.First.Hash(), "second", evidence.Second.Hash())
+	return storeEvidence(c.db, append(known, evidence))
+}
+
+func (c *DPos) Evidence() ([]*Evidence, error) {
+	c.evidenceLock.Lock()
+	defer c.evidenceLock.Unlock()
+
+	return loadEvidence(c.db, c.signatures)
+}
+
+func slashedKey(signer common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-slashed"), signer[:])
+}
+
+func penaltyKey(signer common.Address, number uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-penalty"), signer[:], new(big.Int).SetUint64(number).Bytes())
+}
+
+func IsSlashed(statedb *state.StateDB, signer common.Address) bool {
+	return statedb.GetState(RegistryAddress, slashedKey(signer)) != (common.Hash{})
+}
+
+func slash(statedb *state.StateDB, evidence *Evidence) {
+	signer := evidence.Signer
+	if statedb.GetState(RegistryAddress, penaltyKey(signer, evidence.Number)) != (common.Hash{}) {
+		return
+	}
+	touchRegistry(statedb)
+
+	setBig(statedb, penaltyKey(signer, evidence.Number), big.NewInt(1))
+	setBig(statedb, slashedKey(signer), big.NewInt(1))
+
+	burnt := getBig(statedb, delegationKey(signer, signer))
+	if burnt.Sign() > 0 {
+		setBig(statedb, delegationKey(signer, signer), new(big.Int))
+		setBig(statedb, debtKey(signer, signer), new(big.Int))
+		setBig(statedb, stakeKey(signer), new(big.Int).Sub(getBig(statedb, stakeKey(signer)), burnt))
+		statedb.SubBalance(RegistryAddress, burnt)
+	}
+}
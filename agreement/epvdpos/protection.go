@@ -0,0 +1,233 @@
+package epvdpos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/math"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/process"
+)
+
+const ProtectionVersion = 1
+
+var (
+	signedPrefix = []byte("epvdpos-signed-")
+
+	errConflictingSignature = errors.New("refusing to sign conflicting block at signed height")
+	errProtectionVersion    = errors.New("unsupported slashing protection interchange version")
+	errUnexportableDatabase = errors.New("database does not support export")
+)
+
+// SignedBlock is a single entry of the slashing protection history. SigningRoot
+// is the hash the signer actually signed (the header hash without its seal); a
+// zero SigningRoot marks a height at which conflicting signatures were already
+// produced and no further signature may be released.
+type SignedBlock struct {
+	Number      math.HexOrDecimal64 `json:"number"`
+	SigningRoot common.Hash         `json:"signingRoot"`
+	ParentHash  common.Hash         `json:"parentHash"`
+}
+
+type SignerHistory struct {
+	Signer common.Address `json:"signer"`
+	Blocks []SignedBlock  `json:"blocks"`
+}
+
+// ProtectionHistory is the JSON interchange format used to move slashing
+// protection data between nodes:
+//
+//   {
+//     "version": 1,
+//     "signers": [{
+//       "signer": "0x...",
+//       "blocks": [{"number": "0x10", "signingRoot": "0x...", "parentHash": "0x..."}]
+//     }]
+//   }
+type ProtectionHistory struct {
+	Version uint64          `json:"version"`
+	Signers []SignerHistory `json:"signers"`
+}
+
+type signedRLP struct {
+	SigningRoot common.Hash
+	ParentHash  common.Hash
+}
+
+type SlashingProtection struct {
+	db   epvdb.Database
+	lock sync.Mutex
+}
+
+func NewSlashingProtection(db epvdb.Database) *SlashingProtection {
+	return &SlashingProtection{db: db}
+}
+
+func signedKey(signer common.Address, number uint64) []byte {
+	key := make([]byte, len(signedPrefix)+common.AddressLength+8)
+	copy(key, signedPrefix)
+	copy(key[len(signedPrefix):], signer[:])
+	binary.BigEndian.PutUint64(key[len(signedPrefix)+common.AddressLength:], number)
+	return key
+}
+
+func (p *SlashingProtection) signed(signer common.Address, number uint64) (*signedRLP, error) {
+	blob, err := p.db.Get(signedKey(signer, number))
+	if err != nil || len(blob) == 0 {
+		return nil, nil
+	}
+	record := new(signedRLP)
+	if err := rlp.DecodeBytes(blob, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (p *SlashingProtection) store(signer common.Address, number uint64, record *signedRLP) error {
+	blob, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	return p.db.Put(signedKey(signer, number), blob)
+}
+
+// CheckAndRecord refuses a header conflicting with the one already recorded
+// at its height and records it otherwise. It reports whether the record is
+// new, in which case Release must be called if no signature gets produced.
+func (p *SlashingProtection) CheckAndRecord(signer common.Address, header *types.Header) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	number, root := header.Number.Uint64(), sigHash(header)
+
+	record, err := p.signed(signer, number)
+	if err != nil {
+		return false, err
+	}
+	if record != nil {
+		if record.SigningRoot != root {
+			log.Error("Slashing protection refused to sign", "signer", signer, "number", number, "signed", record.SigningRoot, "requested", root)
+			return false, errConflictingSignature
+		}
+		return false, nil
+	}
+	if err := p.store(signer, number, &signedRLP{SigningRoot: root, ParentHash: header.ParentHash}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release drops the record CheckAndRecord made for a header that could not
+// be signed, so the height is free again.
+func (p *SlashingProtection) Release(signer common.Address, header *types.Header) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	number := header.Number.Uint64()
+
+	record, err := p.signed(signer, number)
+	if err != nil || record == nil || record.SigningRoot != sigHash(header) {
+		return err
+	}
+	return p.db.Delete(signedKey(signer, number))
+}
+
+func (p *SlashingProtection) Export(w io.Writer) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var keys [][]byte
+	switch db := p.db.(type) {
+	case *epvdb.LDBDatabase:
+		it := db.NewIterator()
+		for it.Seek(signedPrefix); it.Valid() && bytes.HasPrefix(it.Key(), signedPrefix); it.Next() {
+			keys = append(keys, common.CopyBytes(it.Key()))
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	case *epvdb.MemDatabase:
+		for _, key := range db.Keys() {
+			if bytes.HasPrefix(key, signedPrefix) {
+				keys = append(keys, key)
+			}
+		}
+	default:
+		return errUnexportableDatabase
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+	history := &ProtectionHistory{Version: ProtectionVersion, Signers: []SignerHistory{}}
+	for _, key := range keys {
+		if len(key) != len(signedPrefix)+common.AddressLength+8 {
+			continue
+		}
+		signer := common.BytesToAddress(key[len(signedPrefix) : len(signedPrefix)+common.AddressLength])
+		number := binary.BigEndian.Uint64(key[len(signedPrefix)+common.AddressLength:])
+
+		record, err := p.signed(signer, number)
+		if err != nil {
+			return fmt.Errorf("corrupted slashing protection record for %x at %d: %v", signer, number, err)
+		}
+		if n := len(history.Signers); n == 0 || history.Signers[n-1].Signer != signer {
+			history.Signers = append(history.Signers, SignerHistory{Signer: signer})
+		}
+		entry := &history.Signers[len(history.Signers)-1]
+		entry.Blocks = append(entry.Blocks, SignedBlock{
+			Number:      math.HexOrDecimal64(number),
+			SigningRoot: record.SigningRoot,
+			ParentHash:  record.ParentHash,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(history)
+}
+
+func (p *SlashingProtection) Import(r io.Reader) (int, error) {
+	var history ProtectionHistory
+	if err := json.NewDecoder(r).Decode(&history); err != nil {
+		return 0, err
+	}
+	if history.Version != ProtectionVersion {
+		return 0, errProtectionVersion
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	imported := 0
+	for _, signer := range history.Signers {
+		for _, block := range signer.Blocks {
+			number := uint64(block.Number)
+
+			record, err := p.signed(signer.Signer, number)
+			if err != nil {
+				return imported, err
+			}
+			switch {
+			case record == nil:
+				record = &signedRLP{SigningRoot: block.SigningRoot, ParentHash: block.ParentHash}
+			case record.SigningRoot == block.SigningRoot:
+				continue
+			default:
+				log.Warn("Conflicting signature history imported, locking height", "signer", signer.Signer, "number", number)
+				record = &signedRLP{}
+			}
+			if err := p.store(signer.Signer, number, record); err != nil {
+				return imported, err
+			}
+			imported++
+		}
+	}
+	return imported, nil
+}
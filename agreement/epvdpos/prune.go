@@ -0,0 +1,88 @@
+package epvdpos
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/agreement"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+)
+
+var errUnprunableDatabase = errors.New("database does not support iteration")
+
+type PruneStats struct {
+	Kept      int
+	Migrated  int
+	Orphaned  int
+	Expired   int
+	Corrupted int
+}
+
+func PruneArchives(chain consensus.ChainReader, db epvdb.Database, retention uint64) (*PruneStats, error) {
+	ldb, ok := db.(*epvdb.LDBDatabase)
+	if !ok {
+		return nil, errUnprunableDatabase
+	}
+	var (
+		head   = chain.CurrentHeader().Number.Uint64()
+		stats  = new(PruneStats)
+		latest *Archive
+		stale  [][]byte
+	)
+	it := ldb.NewIterator()
+	defer it.Release()
+
+	for it.Seek(archivePrefix); it.Valid() && bytes.HasPrefix(it.Key(), archivePrefix); it.Next() {
+		if !isArchiveKey(it.Key()) {
+			continue
+		}
+		key := common.CopyBytes(it.Key())
+
+		archive, legacy, err := decodeArchive(it.Value())
+		if err != nil {
+			log.Warn("Dropping corrupted voting archive", "key", common.Bytes2Hex(key), "err", err)
+			stats.Corrupted++
+			stale = append(stale, key)
+			continue
+		}
+		if header := chain.GetHeaderByNumber(archive.Number); header == nil || header.Hash() != archive.Hash {
+			stats.Orphaned++
+			stale = append(stale, key)
+			continue
+		}
+		if latest == nil || archive.Number > latest.Number {
+			latest = archive
+		}
+		if archive.Number > 0 && archive.Number+retention < head {
+			stats.Expired++
+			stale = append(stale, key)
+			continue
+		}
+		if legacy {
+			if err := archive.store(db); err != nil {
+				return nil, err
+			}
+			stats.Migrated++
+		}
+		stats.Kept++
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	for _, key := range stale {
+		if latest != nil && bytes.Equal(key, archiveKey(latest.Hash)) {
+			if err := latest.store(db); err != nil {
+				return nil, err
+			}
+			stats.Expired--
+			stats.Kept++
+			continue
+		}
+		if err := db.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
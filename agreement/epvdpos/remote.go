@@ -0,0 +1,134 @@
+package epvdpos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/epvchain/go-epvchain/act"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/remote"
+)
+
+const DefaultSignerTimeout = 5 * time.Second
+
+var (
+	errInvalidSignature = errors.New("invalid remote signature length")
+	errSignerMismatch   = errors.New("remote signature does not match signer")
+)
+
+type HeaderSignerFn func(accounts.Account, *types.Header) ([]byte, error)
+
+type RemoteSignerError struct {
+	Endpoint string
+	Refused  bool
+	Err      error
+}
+
+func (e *RemoteSignerError) Error() string {
+	if e.Refused {
+		return fmt.Sprintf("remote signer %s refused to sign: %v", e.Endpoint, e.Err)
+	}
+	return fmt.Sprintf("remote signer %s unreachable: %v", e.Endpoint, e.Err)
+}
+
+type RemoteSigner struct {
+	endpoint string
+	timeout  time.Duration
+
+	client *rpc.Client
+	lock   sync.Mutex
+}
+
+func NewRemoteSigner(endpoint string, timeout time.Duration) *RemoteSigner {
+	if timeout <= 0 {
+		timeout = DefaultSignerTimeout
+	}
+	return &RemoteSigner{endpoint: endpoint, timeout: timeout}
+}
+
+func (s *RemoteSigner) Endpoint() string {
+	return s.endpoint
+}
+
+func (s *RemoteSigner) SignHeader(account accounts.Account, header *types.Header) ([]byte, error) {
+	hash := sigHash(header)
+
+	var sig hexutil.Bytes
+	if err := s.call(&sig, "dpos_signHeader", account.Address, header, hash); err != nil {
+		return nil, err
+	}
+	return s.verify(account.Address, hash, sig)
+}
+
+func (s *RemoteSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.call(&sig, "dpos_signHash", account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	return s.verify(account.Address, common.BytesToHash(hash), sig)
+}
+
+func (s *RemoteSigner) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return &RemoteSignerError{Endpoint: s.endpoint, Err: err}
+	}
+	if err := client.CallContext(ctx, result, method, args...); err != nil {
+		if _, ok := err.(rpc.Error); ok {
+			return &RemoteSignerError{Endpoint: s.endpoint, Refused: true, Err: err}
+		}
+		s.reset(client)
+		return &RemoteSignerError{Endpoint: s.endpoint, Err: err}
+	}
+	return nil
+}
+
+func (s *RemoteSigner) dial(ctx context.Context) (*rpc.Client, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+	client, err := rpc.DialContext(ctx, s.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+	return client, nil
+}
+
+func (s *RemoteSigner) reset(client *rpc.Client) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.client == client {
+		s.client.Close()
+		s.client = nil
+	}
+}
+
+func (s *RemoteSigner) verify(signer common.Address, hash common.Hash, sig []byte) ([]byte, error) {
+	if len(sig) != extraSeal {
+		return nil, &RemoteSignerError{Endpoint: s.endpoint, Refused: true, Err: errInvalidSignature}
+	}
+	pubkey, err := crypto.Ecrecover(hash.Bytes(), sig)
+	if err != nil {
+		return nil, &RemoteSignerError{Endpoint: s.endpoint, Refused: true, Err: err}
+	}
+	var recovered common.Address
+	copy(recovered[:], crypto.Keccak256(pubkey[1:])[12:])
+	if recovered != signer {
+		return nil, &RemoteSignerError{Endpoint: s.endpoint, Refused: true, Err: errSignerMismatch}
+	}
+	return sig, nil
+}
@@ -0,0 +1,116 @@
+package epvdpos
+
+import (
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/content"
+)
+
+var (
+	rewardPrecision = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	hundred         = big.NewInt(100)
+)
+
+func accKey(candidate common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-acc"), candidate[:])
+}
+
+func debtKey(delegator, candidate common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-debt"), delegator[:], candidate[:])
+}
+
+func beneficiaryKey(signer common.Address) common.Hash {
+	return crypto.Keccak256Hash([]byte("epvdpos-beneficiary"), signer[:])
+}
+
+func Beneficiary(statedb *state.StateDB, signer common.Address) common.Address {
+	if beneficiary := statedb.GetState(RegistryAddress, beneficiaryKey(signer)); beneficiary != (common.Hash{}) {
+		return common.BytesToAddress(beneficiary[:])
+	}
+	return signer
+}
+
+func setBeneficiary(statedb *state.StateDB, signer, beneficiary common.Address) {
+	touchRegistry(statedb)
+	statedb.SetState(RegistryAddress, beneficiaryKey(signer), beneficiary.Hash())
+}
+
+func settle(statedb *state.StateDB, delegator, candidate common.Address) {
+	accrued := new(big.Int).Mul(getBig(statedb, delegationKey(delegator, candidate)), getBig(statedb, accKey(candidate)))
+	accrued.Div(accrued, rewardPrecision)
+
+	pending := accrued.Sub(accrued, getBig(statedb, debtKey(delegator, candidate)))
+	if balance := statedb.GetBalance(RegistryAddress); pending.Cmp(balance) > 0 {
+		pending.Set(balance)
+	}
+	if pending.Sign() > 0 {
+		statedb.SubBalance(RegistryAddress, pending)
+		statedb.AddBalance(delegator, pending)
+	}
+}
+
+func resetDebt(statedb *state.StateDB, delegator, candidate common.Address) {
+	debt := new(big.Int).Mul(getBig(statedb, delegationKey(delegator, candidate)), getBig(statedb, accKey(candidate)))
+	setBig(statedb, debtKey(delegator, candidate), debt.Div(debt, rewardPrecision))
+}
+
+func distribute(statedb *state.StateDB, candidate common.Address, amount *big.Int) bool {
+	stake := getBig(statedb, stakeKey(candidate))
+	if stake.Sign() <= 0 {
+		return false
+	}
+	share := new(big.Int).Mul(amount, rewardPrecision)
+	share.Div(share, stake)
+
+	setBig(statedb, accKey(candidate), share.Add(share, getBig(statedb, accKey(candidate))))
+	statedb.AddBalance(RegistryAddress, amount)
+	return true
+}
+
+func blockReward(config *params.DPosConfig, number *big.Int) *big.Int {
+	if config.BlockReward == nil || config.BlockReward.Sign() <= 0 {
+		return new(big.Int)
+	}
+	reward := new(big.Int).Set(config.BlockReward)
+	if config.RewardHalving > 0 && config.RewardBlock != nil {
+		halvings := new(big.Int).Sub(number, config.RewardBlock).Uint64() / config.RewardHalving
+		if halvings >= uint64(reward.BitLen()) {
+			return new(big.Int)
+		}
+		reward.Rsh(reward, uint(halvings))
+	}
+	return reward
+}
+
+func accumulateRewards(config *params.DPosConfig, statedb *state.StateDB, header *types.Header, signer common.Address) {
+	reward := blockReward(config, header.Number)
+	if reward.Sign() == 0 {
+		return
+	}
+	remaining := new(big.Int).Set(reward)
+
+	if config.Treasury != (common.Address{}) && config.TreasuryShare > 0 {
+		share := new(big.Int).Mul(reward, new(big.Int).SetUint64(config.TreasuryShare))
+		share.Div(share, hundred)
+		if share.Cmp(remaining) > 0 {
+			share.Set(remaining)
+		}
+		statedb.AddBalance(config.Treasury, share)
+		remaining.Sub(remaining, share)
+	}
+	if config.DelegatorShare > 0 {
+		share := new(big.Int).Mul(reward, new(big.Int).SetUint64(config.DelegatorShare))
+		share.Div(share, hundred)
+		if share.Cmp(remaining) > 0 {
+			share.Set(remaining)
+		}
+		if distribute(statedb, signer, share) {
+			remaining.Sub(remaining, share)
+		}
+	}
+	statedb.AddBalance(Beneficiary(statedb, signer), remaining)
+}
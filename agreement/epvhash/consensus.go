@@ -232,6 +232,9 @@ func (epvhash *EPVhash) verifyHeader(chain consensus.ChainReader, header, parent
 	if diff := new(big.Int).Sub(header.Number, parent.Number); diff.Cmp(big.NewInt(1)) != 0 {
 		return consensus.ErrInvalidNumber
 	}
+	if err := misc.VerifyBaseFee(chain.Config(), parent, header); err != nil {
+		return err
+	}
 
 	if seal {
 		if err := epvhash.VerifySeal(chain, header); err != nil {
@@ -0,0 +1,66 @@
+package misc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public/math"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/content"
+)
+
+var (
+	ErrMissingBaseFee = errors.New("header is missing base fee")
+
+	ErrUnexpectedBaseFee = errors.New("base fee present before fork")
+)
+
+// VerifyBaseFee checks that the header carries a base fee exactly when the
+// base fee fork is active, and that it follows from the parent's gas usage.
+func VerifyBaseFee(config *params.ChainConfig, parent, header *types.Header) error {
+	if !config.IsBaseFee(header.Number) {
+		if header.BaseFee != nil {
+			return ErrUnexpectedBaseFee
+		}
+		return nil
+	}
+	if header.BaseFee == nil {
+		return ErrMissingBaseFee
+	}
+	if expected := CalcBaseFee(config, parent); header.BaseFee.Cmp(expected) != 0 {
+		return fmt.Errorf("invalid baseFee: have %v, want %v, parentBaseFee %v, parentGasUsed %d",
+			header.BaseFee, expected, parent.BaseFee, parent.GasUsed)
+	}
+	return nil
+}
+
+// CalcBaseFee returns the base fee of the block following parent. The fee
+// moves by at most 1/BaseFeeChangeDenominator per block towards keeping
+// blocks at half of the gas limit.
+func CalcBaseFee(config *params.ChainConfig, parent *types.Header) *big.Int {
+	if !config.IsBaseFee(parent.Number) || parent.BaseFee == nil {
+		return new(big.Int).SetUint64(params.InitialBaseFee)
+	}
+	target := parent.GasLimit / params.ElasticityMultiplier
+	if target == 0 || parent.GasUsed == target {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+	var (
+		denominator = new(big.Int).SetUint64(params.BaseFeeChangeDenominator)
+		targetBig   = new(big.Int).SetUint64(target)
+	)
+	if parent.GasUsed > target {
+		delta := new(big.Int).SetUint64(parent.GasUsed - target)
+		delta.Mul(delta, parent.BaseFee)
+		delta.Div(delta, targetBig)
+		delta.Div(delta, denominator)
+		delta = math.BigMax(delta, big.NewInt(1))
+		return delta.Add(delta, parent.BaseFee)
+	}
+	delta := new(big.Int).SetUint64(target - parent.GasUsed)
+	delta.Mul(delta, parent.BaseFee)
+	delta.Div(delta, targetBig)
+	delta.Div(delta, denominator)
+	return math.BigMax(delta.Sub(parent.BaseFee, delta), new(big.Int))
+}
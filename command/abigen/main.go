@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/epvchain/go-epvchain/act/abi/bind"
+	"github.com/epvchain/go-epvchain/command/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	gitCommit = ""
+
+	app = utils.NewApp(gitCommit, "the go-epvchain contract binding generator")
+
+	abiFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "Path to the contract ABI json to bind, - for STDIN",
+	}
+	binFlag = cli.StringFlag{
+		Name:  "bin",
+		Usage: "Path to the contract bytecode (generate deploy method)",
+	}
+	typeFlag = cli.StringFlag{
+		Name:  "type",
+		Usage: "Go struct name for the binding (default = ABI file name)",
+	}
+	pkgFlag = cli.StringFlag{
+		Name:  "pkg",
+		Usage: "Go package name to generate the binding into",
+	}
+	outFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Output file for the generated binding (default = stdout)",
+	}
+)
+
+func init() {
+	app.Action = abigen
+	app.Copyright = "Copyright 2018 The go-epvchain Authors"
+	app.Flags = []cli.Flag{
+		abiFlag,
+		binFlag,
+		typeFlag,
+		pkgFlag,
+		outFlag,
+	}
+}
+
+func abigen(ctx *cli.Context) error {
+	if ctx.GlobalString(abiFlag.Name) == "" {
+		utils.Fatalf("No contract ABI specified (--abi)")
+	}
+	if ctx.GlobalString(pkgFlag.Name) == "" {
+		utils.Fatalf("No destination package specified (--pkg)")
+	}
+	var (
+		abiPath = ctx.GlobalString(abiFlag.Name)
+		abiJSON []byte
+		err     error
+	)
+	if abiPath == "-" {
+		abiJSON, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		abiJSON, err = ioutil.ReadFile(abiPath)
+	}
+	if err != nil {
+		utils.Fatalf("Failed to read input ABI: %v", err)
+	}
+	var bytecode []byte
+	if binFile := ctx.GlobalString(binFlag.Name); binFile != "" {
+		if bytecode, err = ioutil.ReadFile(binFile); err != nil {
+			utils.Fatalf("Failed to read input bytecode: %v", err)
+		}
+	}
+	kind := ctx.GlobalString(typeFlag.Name)
+	if kind == "" {
+		if abiPath == "-" {
+			utils.Fatalf("No binding type specified (--type)")
+		}
+		kind = strings.TrimSuffix(filepath.Base(abiPath), filepath.Ext(abiPath))
+	}
+	code, err := bind.Bind([]string{kind}, []string{string(abiJSON)}, []string{string(bytecode)}, ctx.GlobalString(pkgFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to generate ABI binding: %v", err)
+	}
+	if out := ctx.GlobalString(outFlag.Name); out != "" {
+		if err := ioutil.WriteFile(out, []byte(code), 0600); err != nil {
+			utils.Fatalf("Failed to write ABI binding: %v", err)
+		}
+		return nil
+	}
+	fmt.Printf("%s\n", code)
+	return nil
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -53,6 +53,7 @@ It expects the genesis file as argument.`,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 		},
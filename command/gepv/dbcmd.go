@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/epvchain/go-epvchain/command/utils"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var dbCommand = cli.Command{
+	Name:     "db",
+	Usage:    "Low level database operations",
+	Category: "MISCELLANEOUS COMMANDS",
+	Description: `
+Operate on the layout of the local chain database.`,
+	Subcommands: []cli.Command{
+		{
+			Action:    utils.MigrateFlags(freezeAncients),
+			Name:      "freeze",
+			Usage:     "Move old canonical blocks into the ancient store",
+			ArgsUsage: " ",
+			Category:  "MISCELLANEOUS COMMANDS",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.CacheFlag,
+				utils.TestnetFlag,
+				utils.RinkebyFlag,
+				utils.AncientThresholdFlag,
+				utils.AncientCompressFlag,
+			},
+			Description: `
+    gepv db freeze --ancient.threshold <blocks>
+
+Moves every canonical block older than the given number of blocks behind the
+chain head from the key-value database into the append-only ancient store,
+deleting side chain data at the same heights. Frozen blocks remain available
+through all the usual APIs. The ancient store lives in the "ancient" folder of
+the chain database and is created if missing.`,
+		},
+	},
+}
+
+func freezeAncients(ctx *cli.Context) error {
+	threshold := ctx.GlobalUint64(utils.AncientThresholdFlag.Name)
+	if threshold == 0 {
+		utils.Fatalf("The --%s flag is required", utils.AncientThresholdFlag.Name)
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	db, ok := chainDb.(*epvdb.LDBDatabase)
+	if !ok {
+		utils.Fatalf("Ancient store requires a persistent database")
+	}
+	head := core.GetBlockNumber(db, core.GetHeadBlockHash(db))
+	if fast := core.GetBlockNumber(db, core.GetHeadFastBlockHash(db)); fast < head {
+		head = fast
+	}
+	if head <= threshold {
+		return fmt.Errorf("chain head %d is within the threshold of %d blocks", head, threshold)
+	}
+	var (
+		start  = time.Now()
+		frozen uint64
+	)
+	for {
+		n, err := core.FreezeAncients(db, head-threshold)
+		if err != nil {
+			utils.Fatalf("Failed to freeze ancient blocks: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		frozen += n
+	}
+	log.Info("Ancient store updated", "frozen", frozen, "items", db.Ancients(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
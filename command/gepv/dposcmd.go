@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/epvchain/go-epvchain/command/utils"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/agreement/epvdpos"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	archiveRetentionFlag = cli.Uint64Flag{
+		Name:  "retention",
+		Value: 90000,
+		Usage: "Number of recent blocks whose canonical voting archives are retained",
+	}
+	dposCommand = cli.Command{
+		Name:     "dpos",
+		Usage:    "Manage DPoS consensus data",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Manage the data maintained by the DPoS consensus engine.`,
+		Subcommands: []cli.Command{
+			{
+				Name:     "archives",
+				Usage:    "Manage stored voting archives",
+				Category: "BLOCKCHAIN COMMANDS",
+				Subcommands: []cli.Command{
+					{
+						Action:    utils.MigrateFlags(pruneArchives),
+						Name:      "prune",
+						Usage:     "Delete side chain and expired voting archives",
+						ArgsUsage: " ",
+						Category:  "BLOCKCHAIN COMMANDS",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.CacheFlag,
+							utils.TestnetFlag,
+							utils.RinkebyFlag,
+							archiveRetentionFlag,
+						},
+						Description: `
+    gepv dpos archives prune [--retention blocks]
+
+Deletes every stored voting archive that is not on the canonical chain or is
+older than the retention window, converting legacy JSON archives to the
+compact format on the way. The most recent canonical archive is always kept.`,
+					},
+				},
+			},
+			{
+				Name:     "protection",
+				Usage:    "Manage the validator slashing protection history",
+				Category: "BLOCKCHAIN COMMANDS",
+				Subcommands: []cli.Command{
+					{
+						Action:    utils.MigrateFlags(exportProtection),
+						Name:      "export",
+						Usage:     "Export the slashing protection history to a JSON file",
+						ArgsUsage: "<filename>",
+						Category:  "BLOCKCHAIN COMMANDS",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.CacheFlag,
+							utils.TestnetFlag,
+							utils.RinkebyFlag,
+						},
+						Description: `
+    gepv dpos protection export <filename>
+
+Writes every block signature recorded by this node's slashing protection store
+to the given file in the JSON interchange format, so that the history can move
+together with the validator key.`,
+					},
+					{
+						Action:    utils.MigrateFlags(importProtection),
+						Name:      "import",
+						Usage:     "Import a slashing protection history from a JSON file",
+						ArgsUsage: "<filename>",
+						Category:  "BLOCKCHAIN COMMANDS",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.CacheFlag,
+							utils.TestnetFlag,
+							utils.RinkebyFlag,
+						},
+						Description: `
+    gepv dpos protection import <filename>
+
+Merges a slashing protection history exported by another node into the local
+store. Heights for which the two histories disagree are locked and will never
+be signed again.`,
+					},
+				},
+			},
+		},
+	}
+)
+
+func pruneArchives(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	if chain.Config().DPos == nil {
+		utils.Fatalf("Chain is not using the DPoS consensus engine")
+	}
+	start := time.Now()
+
+	stats, err := epvdpos.PruneArchives(chain, chainDb, ctx.Uint64(archiveRetentionFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to prune voting archives: %v", err)
+	}
+	fmt.Printf("Pruned voting archives in %v: kept %d (migrated %d), deleted %d side chain, %d expired, %d corrupted\n",
+		common.PrettyDuration(time.Since(start)), stats.Kept, stats.Migrated, stats.Orphaned, stats.Expired, stats.Corrupted)
+
+	if ldb, ok := chainDb.(*epvdb.LDBDatabase); ok {
+		start = time.Now()
+		fmt.Println("Compacting voting archives...")
+		if err := ldb.LDB().CompactRange(util.Range{Start: []byte("epvdpos-"), Limit: []byte("epvdpos.")}); err != nil {
+			utils.Fatalf("Compaction failed: %v", err)
+		}
+		fmt.Printf("Compaction done in %v.\n", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
+func exportProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	out, err := os.Create(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to create export file: %v", err)
+	}
+	defer out.Close()
+
+	if err := epvdpos.NewSlashingProtection(chainDb).Export(out); err != nil {
+		utils.Fatalf("Failed to export slashing protection history: %v", err)
+	}
+	fmt.Printf("Exported slashing protection history to %s\n", ctx.Args().First())
+	return nil
+}
+
+func importProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	in, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to open import file: %v", err)
+	}
+	defer in.Close()
+
+	imported, err := epvdpos.NewSlashingProtection(chainDb).Import(in)
+	if err != nil {
+		utils.Fatalf("Failed to import slashing protection history: %v", err)
+	}
+	fmt.Printf("Imported %d signed blocks\n", imported)
+	return nil
+}
@@ -70,6 +70,9 @@ var (
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.SnapshotFlag,
+		utils.AncientThresholdFlag,
+		utils.AncientCompressFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -81,6 +84,8 @@ var (
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
 		utils.EPVCbaseFlag,
+		utils.DPosSignerFlag,
+		utils.DPosSignerTimeoutFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
@@ -143,6 +148,9 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		dposCommand,
+		snapshotCommand,
+		dbCommand,
 
 		monitorCommand,
 
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"time"
+
+	"github.com/epvchain/go-epvchain/command/utils"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/kernel/state/pruner"
+	"github.com/epvchain/go-epvchain/kernel/state/snapshot"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	bloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Value: 2048,
+		Usage: "Megabytes of memory allocated to the bloom filter marking reachable state",
+	}
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "A set of commands based on the state snapshot",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+Operate on the state of the local chain database.`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(pruneState),
+				Name:      "prune-state",
+				Usage:     "Prune stale state data from the database",
+				ArgsUsage: "<root>",
+				Category:  "MISCELLANEOUS COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.TestnetFlag,
+					utils.RinkebyFlag,
+					bloomFilterSizeFlag,
+				},
+				Description: `
+    gepv snapshot prune-state [root]
+
+Deletes every trie node and contract code entry that is not reachable from the
+given state root, or from the most recent committed state of the chain head if
+no root is specified. Reachable data is marked in a bloom filter, so a small
+fraction of stale data may survive. The database is compacted afterwards.
+
+The most recent committed state may lie below the chain head, in which case
+the chain is rewound to it on the next start.
+
+The command can be safely interrupted: once marking has completed its result
+is persisted in the data directory, and rerunning the command resumes the
+sweep with the same root. A node started on such a database finishes the
+sweep before opening the chain.`,
+			},
+			{
+				Action:    utils.MigrateFlags(verifyState),
+				Name:      "verify-state",
+				Usage:     "Recalculate the state root from the flat snapshot and verify it",
+				ArgsUsage: "<root>",
+				Category:  "MISCELLANEOUS COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.TestnetFlag,
+					utils.RinkebyFlag,
+				},
+				Description: `
+    gepv snapshot verify-state [root]
+
+Rebuilds the state trie from the persisted flat snapshot and checks that its
+root matches the given state root, or the state root of the chain head if no
+root is specified. The snapshot must have been fully generated.`,
+			},
+		},
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	ldb, ok := chainDb.(*epvdb.LDBDatabase)
+	if !ok {
+		utils.Fatalf("State pruning requires a leveldb database")
+	}
+	p := pruner.NewPruner(ldb, stack.InstanceDir(), ctx.Uint64(bloomFilterSizeFlag.Name)*1024*1024)
+
+	var root common.Hash
+	switch {
+	case len(ctx.Args()) > 0:
+		if root = common.HexToHash(ctx.Args().First()); root == (common.Hash{}) {
+			utils.Fatalf("Invalid state root %q", ctx.Args().First())
+		}
+	case p.Interrupted():
+	default:
+		var (
+			number uint64
+			err    error
+		)
+		if root, number, err = p.RecentRoot(); err != nil {
+			utils.Fatalf("Failed to find pruning target: %v", err)
+		}
+		log.Info("Selected pruning target", "number", number, "root", root)
+	}
+	start := time.Now()
+	if err := p.Prune(root); err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	log.Info("State pruning successful", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func verifyState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	var root common.Hash
+	if len(ctx.Args()) > 0 {
+		if root = common.HexToHash(ctx.Args().First()); root == (common.Hash{}) {
+			utils.Fatalf("Invalid state root %q", ctx.Args().First())
+		}
+	} else {
+		head := core.GetHeadBlockHash(chainDb)
+		header := core.GetHeader(chainDb, head, core.GetBlockNumber(chainDb, head))
+		if header == nil {
+			utils.Fatalf("Failed to load head block")
+		}
+		root = header.Root
+	}
+	start := time.Now()
+	if err := snapshot.Verify(chainDb, root); err != nil {
+		utils.Fatalf("State snapshot verification failed: %v", err)
+	}
+	log.Info("Verified state snapshot", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
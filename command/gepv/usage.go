@@ -56,6 +56,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
+			utils.AncientThresholdFlag,
+			utils.AncientCompressFlag,
 			utils.EPVStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -156,6 +159,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MiningEnabledFlag,
 			utils.MinerThreadsFlag,
 			utils.EPVCbaseFlag,
+			utils.DPosSignerFlag,
+			utils.DPosSignerTimeoutFlag,
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
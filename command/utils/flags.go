@@ -151,6 +151,18 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	SnapshotFlag = cli.BoolFlag{
+		Name:  "snapshot",
+		Usage: "Maintain a flat snapshot of the state to accelerate state reads",
+	}
+	AncientThresholdFlag = cli.Uint64Flag{
+		Name:  "ancient.threshold",
+		Usage: "Number of recent blocks kept in the database, older ones are moved to the ancient store (0 = disabled)",
+	}
+	AncientCompressFlag = cli.BoolFlag{
+		Name:  "ancient.compress",
+		Usage: "Compress newly created ancient store tables",
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -311,6 +323,15 @@ var (
 		Usage: "Public address for block mining rewards (default = first account created)",
 		Value: "0",
 	}
+	DPosSignerFlag = cli.StringFlag{
+		Name:  "dpos.signer",
+		Usage: "External signer endpoint (IPC path or HTTP URL) used to seal DPoS blocks",
+	}
+	DPosSignerTimeoutFlag = cli.DurationFlag{
+		Name:  "dpos.signertimeout",
+		Usage: "Maximum time to wait for the external DPoS signer",
+		Value: epvdpos.DefaultSignerTimeout,
+	}
 	GasPriceFlag = BigFlag{
 		Name:  "gasprice",
 		Usage: "Minimal gas price to accept for mining a transactions",
@@ -986,6 +1007,9 @@ func SetEPVConfig(ctx *cli.Context, stack *node.Node, cfg *epv.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.Snapshot = ctx.GlobalBool(SnapshotFlag.Name)
+	cfg.AncientThreshold = ctx.GlobalUint64(AncientThresholdFlag.Name)
+	cfg.AncientCompress = ctx.GlobalBool(AncientCompressFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -993,6 +1017,12 @@ func SetEPVConfig(ctx *cli.Context, stack *node.Node, cfg *epv.Config) {
 	if ctx.GlobalIsSet(MinerThreadsFlag.Name) {
 		cfg.MinerThreads = ctx.GlobalInt(MinerThreadsFlag.Name)
 	}
+	if ctx.GlobalIsSet(DPosSignerFlag.Name) {
+		cfg.DPosSigner = ctx.GlobalString(DPosSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(DPosSignerTimeoutFlag.Name) {
+		cfg.DPosSignerTimeout = ctx.GlobalDuration(DPosSignerTimeoutFlag.Name)
+	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
@@ -1123,6 +1153,12 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) epvdb.Database {
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	if db, ok := chainDb.(*epvdb.LDBDatabase); ok && !ctx.GlobalBool(LightModeFlag.Name) {
+		create := ctx.GlobalUint64(AncientThresholdFlag.Name) > 0
+		if err := core.OpenAncients(db, create, ctx.GlobalBool(AncientCompressFlag.Name)); err != nil {
+			Fatalf("Could not open ancient store: %v", err)
+		}
+	}
 	return chainDb
 }
 
@@ -1170,6 +1206,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: epv.DefaultConfig.TrieCache,
 		TrieTimeLimit: epv.DefaultConfig.TrieTimeout,
+		Snapshot:      ctx.GlobalBool(SnapshotFlag.Name),
+
+		AncientThreshold: ctx.GlobalUint64(AncientThresholdFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -63,10 +63,10 @@ var (
 		},
 	}
 
-	AllEPVhashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EPVhashConfig), nil}
-	AllDPosProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &DPosConfig{Period: 0, Epoch: 30000}}
+	AllEPVhashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EPVhashConfig), nil}
+	AllDPosProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &DPosConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EPVhashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EPVhashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -86,6 +86,22 @@ type ChainConfig struct {
 
 	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` 
 
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"`
+	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`
+	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`
+
+	MillisecondTimeBlock *big.Int `json:"millisecondTimeBlock,omitempty"`
+
+	// AccessListBlock activates typed transactions, EIP-2930 access list
+	// transactions and the warm/cold state access pricing they rely on.
+	AccessListBlock *big.Int `json:"accessListBlock,omitempty"`
+
+	// BaseFeeBlock activates the per-block base fee and dynamic fee
+	// transactions. The base fee part of every fee is burned, or credited
+	// to BaseFeeTreasury when one is configured.
+	BaseFeeBlock    *big.Int        `json:"baseFeeBlock,omitempty"`
+	BaseFeeTreasury *common.Address `json:"baseFeeTreasury,omitempty"`
+
 	EPVhash *EPVhashConfig `json:"epvhash,omitempty"`
 	DPos *DPosConfig `json:"dpos,omitempty"`
 }
@@ -96,15 +112,117 @@ func (c *EPVhashConfig) String() string {
 	return "epvhash"
 }
 
+// DefaultDPosEpoch is the epoch length the DPoS engine falls back to when the
+// config leaves it zero.
+const DefaultDPosEpoch = 30000
+
 type DPosConfig struct {
 	Period uint64 `json:"period"`
 	Epoch  uint64 `json:"epoch"`
+
+	MaxSigners    uint64   `json:"maxSigners,omitempty"`
+	ElectionBlock *big.Int `json:"electionBlock,omitempty"`
+	HashTurnBlock *big.Int `json:"hashTurnBlock,omitempty"`
+
+	RewardBlock    *big.Int       `json:"rewardBlock,omitempty"`
+	BlockReward    *big.Int       `json:"blockReward,omitempty"`
+	RewardHalving  uint64         `json:"rewardHalving,omitempty"`
+	Treasury       common.Address `json:"treasury,omitempty"`
+	TreasuryShare  uint64         `json:"treasuryShare,omitempty"`
+	DelegatorShare uint64         `json:"delegatorShare,omitempty"`
+
+	LivenessBlock *big.Int `json:"livenessBlock,omitempty"`
+	MissThreshold uint64   `json:"missThreshold,omitempty"`
+
+	Schedule []DPosRule `json:"schedule,omitempty"`
+}
+
+type DPosRule struct {
+	Block      *big.Int `json:"block"`
+	Period     uint64   `json:"period,omitempty"`
+	Epoch      uint64   `json:"epoch,omitempty"`
+	MaxSigners uint64   `json:"maxSigners,omitempty"`
 }
 
 func (c *DPosConfig) String() string {
 	return "dpos"
 }
 
+func (c *DPosConfig) At(number uint64) DPosRule {
+	rule := DPosRule{Block: new(big.Int), Period: c.Period, Epoch: c.Epoch, MaxSigners: c.MaxSigners}
+	for _, override := range c.Schedule {
+		if override.Block == nil || !override.Block.IsUint64() || override.Block.Uint64() > number {
+			break
+		}
+		rule.Block = override.Block
+		if override.Period != 0 {
+			rule.Period = override.Period
+		}
+		if override.Epoch != 0 {
+			rule.Epoch = override.Epoch
+		}
+		if override.MaxSigners != 0 {
+			rule.MaxSigners = override.MaxSigners
+		}
+	}
+	return rule
+}
+
+func (c *DPosConfig) IsCheckpoint(number uint64) bool {
+	return number%c.epochAt(number) == 0
+}
+
+// epochAt returns the epoch length in effect at number, falling back to
+// DefaultDPosEpoch when the config leaves it unset.
+func (c *DPosConfig) epochAt(number uint64) uint64 {
+	if epoch := c.At(number).Epoch; epoch != 0 {
+		return epoch
+	}
+	return DefaultDPosEpoch
+}
+
+func (c *DPosConfig) Validate() error {
+	if c.TreasuryShare > 100 || c.DelegatorShare > 100 || c.TreasuryShare+c.DelegatorShare > 100 {
+		return fmt.Errorf("dpos reward shares exceed 100%%: treasury %d%%, delegators %d%%", c.TreasuryShare, c.DelegatorShare)
+	}
+	var last uint64
+	for i, override := range c.Schedule {
+		if override.Block == nil || !override.Block.IsUint64() {
+			return fmt.Errorf("dpos schedule entry %d: missing activation block", i)
+		}
+		block := override.Block.Uint64()
+		if i > 0 && block <= last {
+			return fmt.Errorf("dpos schedule entry %d: block %d not after previous entry %d", i, block, last)
+		}
+		if block == 0 {
+			return fmt.Errorf("dpos schedule entry %d: cannot override genesis rules", i)
+		}
+		for _, epoch := range []uint64{c.epochAt(block - 1), c.epochAt(block)} {
+			if block%epoch != 0 {
+				return fmt.Errorf("dpos schedule entry %d: block %d not on an epoch %d checkpoint", i, block, epoch)
+			}
+		}
+		last = block
+	}
+	return nil
+}
+
+func (c *DPosConfig) IsElection(num *big.Int) bool {
+	return isForked(c.ElectionBlock, num)
+}
+
+func (c *DPosConfig) IsHashTurn(num *big.Int) bool {
+	return isForked(c.HashTurnBlock, num)
+}
+
+func (c *DPosConfig) IsReward(num *big.Int) bool {
+	return isForked(c.RewardBlock, num)
+}
+
+func (c *DPosConfig) IsLiveness(num *big.Int) bool {
+	return c.MissThreshold > 0 && isForked(c.LivenessBlock, num)
+}
+
 func (c *ChainConfig) String() string {
 	var engine interface{}
 	switch {
@@ -115,7 +233,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v MillisecondTime: %v AccessList: %v BaseFee: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -124,6 +242,12 @@ func (c *ChainConfig) String() string {
 		c.EIP155Block,
 		c.EIP158Block,
 		c.ByzantiumBlock,
+		c.ConstantinopleBlock,
+		c.PetersburgBlock,
+		c.IstanbulBlock,
+		c.MillisecondTimeBlock,
+		c.AccessListBlock,
+		c.BaseFeeBlock,
 		engine,
 	)
 }
@@ -152,11 +276,42 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
+func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+	return isForked(c.ConstantinopleBlock, num)
+}
+
+// IsPetersburg reports whether num is past the fork that removes EIP-1283
+// net gas metering again. Chains that enable Constantinople without naming a
+// Petersburg block never activate EIP-1283.
+func (c *ChainConfig) IsPetersburg(num *big.Int) bool {
+	return isForked(c.PetersburgBlock, num) || c.PetersburgBlock == nil && isForked(c.ConstantinopleBlock, num)
+}
+
+func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
+	return isForked(c.IstanbulBlock, num)
+}
+
+func (c *ChainConfig) IsMillisecondTime(num *big.Int) bool {
+	return isForked(c.MillisecondTimeBlock, num)
+}
+
+func (c *ChainConfig) IsAccessList(num *big.Int) bool {
+	return isForked(c.AccessListBlock, num)
+}
+
+func (c *ChainConfig) IsBaseFee(num *big.Int) bool {
+	return isForked(c.BaseFeeBlock, num)
+}
+
 func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	if num == nil {
 		return GasTableHomestead
 	}
 	switch {
+	case c.IsIstanbul(num):
+		return GasTableIstanbul
+	case c.IsConstantinople(num):
+		return GasTableConstantinople
 	case c.IsEIP158(num):
 		return GasTableEIP158
 	case c.IsEIP150(num):
@@ -166,7 +321,15 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	}
 }
 
-func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
+// CheckCompatible checks that newcfg is valid and can replace c for a chain
+// at the given height, returning a *ConfigCompatError if the chain has to be
+// rewound first.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) error {
+	if newcfg.DPos != nil {
+		if err := newcfg.DPos.Validate(); err != nil {
+			return err
+		}
+	}
 	bhead := new(big.Int).SetUint64(height)
 
 	var lasterr *ConfigCompatError
@@ -178,6 +341,9 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *Confi
 		lasterr = err
 		bhead.SetUint64(err.RewindTo)
 	}
+	if lasterr == nil {
+		return nil
+	}
 	return lasterr
 }
 
@@ -206,6 +372,79 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
+		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	if isForkIncompatible(c.PetersburgBlock, newcfg.PetersburgBlock, head) {
+		return newCompatError("Petersburg fork block", c.PetersburgBlock, newcfg.PetersburgBlock)
+	}
+	if isForkIncompatible(c.IstanbulBlock, newcfg.IstanbulBlock, head) {
+		return newCompatError("Istanbul fork block", c.IstanbulBlock, newcfg.IstanbulBlock)
+	}
+	if isForkIncompatible(c.MillisecondTimeBlock, newcfg.MillisecondTimeBlock, head) {
+		return newCompatError("Millisecond time fork block", c.MillisecondTimeBlock, newcfg.MillisecondTimeBlock)
+	}
+	if isForkIncompatible(c.AccessListBlock, newcfg.AccessListBlock, head) {
+		return newCompatError("Access list fork block", c.AccessListBlock, newcfg.AccessListBlock)
+	}
+	if isForkIncompatible(c.BaseFeeBlock, newcfg.BaseFeeBlock, head) {
+		return newCompatError("Base fee fork block", c.BaseFeeBlock, newcfg.BaseFeeBlock)
+	}
+	if c.IsBaseFee(head) && !configAddrEqual(c.BaseFeeTreasury, newcfg.BaseFeeTreasury) {
+		return newCompatError("Base fee treasury", c.BaseFeeBlock, newcfg.BaseFeeBlock)
+	}
+	if c.DPos != nil && newcfg.DPos != nil {
+		if err := c.DPos.checkCompatible(newcfg.DPos, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DPosConfig) checkCompatible(newcfg *DPosConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.ElectionBlock, newcfg.ElectionBlock, head) {
+		return newCompatError("DPoS election fork block", c.ElectionBlock, newcfg.ElectionBlock)
+	}
+	if isForkIncompatible(c.HashTurnBlock, newcfg.HashTurnBlock, head) {
+		return newCompatError("DPoS hash turn fork block", c.HashTurnBlock, newcfg.HashTurnBlock)
+	}
+	if isForkIncompatible(c.RewardBlock, newcfg.RewardBlock, head) {
+		return newCompatError("DPoS reward fork block", c.RewardBlock, newcfg.RewardBlock)
+	}
+	if c.IsReward(head) && (!configNumEqual(c.BlockReward, newcfg.BlockReward) || c.RewardHalving != newcfg.RewardHalving ||
+		c.Treasury != newcfg.Treasury || c.TreasuryShare != newcfg.TreasuryShare || c.DelegatorShare != newcfg.DelegatorShare) {
+		return newCompatError("DPoS reward parameters", c.RewardBlock, newcfg.RewardBlock)
+	}
+	if isForkIncompatible(c.LivenessBlock, newcfg.LivenessBlock, head) {
+		return newCompatError("DPoS liveness fork block", c.LivenessBlock, newcfg.LivenessBlock)
+	}
+	if c.IsLiveness(head) && c.MissThreshold != newcfg.MissThreshold {
+		return newCompatError("DPoS miss threshold", c.LivenessBlock, newcfg.LivenessBlock)
+	}
+	for i := 0; i < len(c.Schedule) || i < len(newcfg.Schedule); i++ {
+		var stored, updated *DPosRule
+		if i < len(c.Schedule) {
+			stored = &c.Schedule[i]
+		}
+		if i < len(newcfg.Schedule) {
+			updated = &newcfg.Schedule[i]
+		}
+		if stored != nil && updated != nil && configNumEqual(stored.Block, updated.Block) &&
+			stored.Period == updated.Period && stored.Epoch == updated.Epoch && stored.MaxSigners == updated.MaxSigners {
+			continue
+		}
+		var storedBlock, updatedBlock *big.Int
+		if stored != nil {
+			storedBlock = stored.Block
+		}
+		if updated != nil {
+			updatedBlock = updated.Block
+		}
+		if isForked(storedBlock, head) || isForked(updatedBlock, head) {
+			return newCompatError(fmt.Sprintf("DPoS schedule entry %d", i), storedBlock, updatedBlock)
+		}
+		break
+	}
 	return nil
 }
 
@@ -230,6 +469,13 @@ func configNumEqual(x, y *big.Int) bool {
 	return x.Cmp(y) == 0
 }
 
+func configAddrEqual(x, y *common.Address) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
+
 type ConfigCompatError struct {
 	What string
 
@@ -262,7 +508,9 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
-	IsByzantium                               bool
+	IsByzantium, IsMillisecondTime            bool
+	IsConstantinople, IsPetersburg, IsIstanbul bool
+	IsAccessList, IsBaseFee                   bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -270,5 +518,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsConstantinople: c.IsConstantinople(num), IsPetersburg: c.IsPetersburg(num), IsIstanbul: c.IsIstanbul(num), IsMillisecondTime: c.IsMillisecondTime(num), IsAccessList: c.IsAccessList(num), IsBaseFee: c.IsBaseFee(num)}
 }
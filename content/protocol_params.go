@@ -46,6 +46,36 @@ const (
 	MemoryGas        uint64 = 3     
 	TxDataNonZeroGas uint64 = 68    
 
+	NetSstoreNoopGas  uint64 = 200   
+	NetSstoreInitGas  uint64 = 20000 
+	NetSstoreCleanGas uint64 = 5000  
+	NetSstoreDirtyGas uint64 = 200   
+
+	NetSstoreClearRefund      uint64 = 15000 
+	NetSstoreResetRefund      uint64 = 4800  
+	NetSstoreResetClearRefund uint64 = 19800 
+
+	SstoreSentryGasEIP2200   uint64 = 2300  
+	SloadGasEIP2200          uint64 = 800   
+	SstoreInitGasEIP2200     uint64 = 20000 
+	SstoreCleanGasEIP2200    uint64 = 5000  
+	SstoreClearRefundEIP2200 uint64 = 15000 
+	SstoreInitRefundEIP2200  uint64 = 19200 
+	SstoreCleanRefundEIP2200 uint64 = 4200  
+
+	Create2Gas     uint64 = 32000 
+	SelfBalanceGas uint64 = 5     
+
+	TxAccessListAddressGas    uint64 = 2400 
+	TxAccessListStorageKeyGas uint64 = 1900 
+	ColdAccountAccessCost     uint64 = 2600 
+	ColdSloadCost             uint64 = 2100 
+	WarmStorageReadCost       uint64 = 100  
+
+	BaseFeeChangeDenominator uint64 = 8          
+	ElasticityMultiplier     uint64 = 2          
+	InitialBaseFee           uint64 = 1000000000 
+
 	MaxCodeSize = 24576 
 
 	EcrecoverGas            uint64 = 3000   
@@ -60,6 +90,12 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  
 	Bn256PairingBaseGas     uint64 = 100000 
 	Bn256PairingPerPointGas uint64 = 80000  
+
+	Bn256AddGasIstanbul             uint64 = 150   
+	Bn256ScalarMulGasIstanbul       uint64 = 6000  
+	Bn256PairingBaseGasIstanbul     uint64 = 45000 
+	Bn256PairingPerPointGasIstanbul uint64 = 34000 
+	Blake2FRoundGas                 uint64 = 1     
 )
 
 var (
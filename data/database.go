@@ -37,6 +37,8 @@ type LDBDatabase struct {
 	quitLock sync.Mutex      
 	quitChan chan chan error 
 
+	ancient *Freezer
+
 	log log.Logger 
 }
 
@@ -137,6 +139,12 @@ func (db *LDBDatabase) Close() {
 			db.log.Error("Metrics collection failed", "err", err)
 		}
 	}
+	if db.ancient != nil {
+		if err := db.ancient.Close(); err != nil {
+			db.log.Error("Failed to close ancient store", "err", err)
+		}
+		db.ancient = nil
+	}
 	err := db.db.Close()
 	if err == nil {
 		db.log.Info("Database closed")
@@ -145,6 +153,35 @@ func (db *LDBDatabase) Close() {
 	}
 }
 
+// OpenFreezer attaches an ancient store rooted at dir to the database.
+func (db *LDBDatabase) OpenFreezer(dir string, compress bool) error {
+	freezer, err := NewFreezer(dir, compress)
+	if err != nil {
+		return err
+	}
+	db.ancient = freezer
+	db.log.Info("Opened ancient store", "dir", dir, "items", freezer.Ancients())
+	return nil
+}
+
+func (db *LDBDatabase) Freezer() *Freezer {
+	return db.ancient
+}
+
+func (db *LDBDatabase) Ancient(kind string, number uint64) ([]byte, error) {
+	if db.ancient == nil {
+		return nil, errOutOfBounds
+	}
+	return db.ancient.Ancient(kind, number)
+}
+
+func (db *LDBDatabase) Ancients() uint64 {
+	if db.ancient == nil {
+		return 0
+	}
+	return db.ancient.Ancients()
+}
+
 func (db *LDBDatabase) LDB() *leveldb.DB {
 	return db.db
 }
@@ -253,6 +290,12 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size++
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -315,6 +358,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
@@ -0,0 +1,146 @@
+package epvdb
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	FreezerHashTable       = "hashes"
+	FreezerHeaderTable     = "headers"
+	FreezerBodiesTable     = "bodies"
+	FreezerReceiptTable    = "receipts"
+	FreezerDifficultyTable = "diffs"
+)
+
+var freezerTables = []string{FreezerHashTable, FreezerHeaderTable, FreezerBodiesTable, FreezerReceiptTable, FreezerDifficultyTable}
+
+// Freezer is an append-only store for immutable canonical chain segments,
+// holding one flat table per kind of block data, all indexed by block number.
+type Freezer struct {
+	frozen uint64
+	tables map[string]*freezerTable
+
+	lock sync.Mutex
+}
+
+func NewFreezer(datadir string, compress bool) (*Freezer, error) {
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	freezer := &Freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(datadir, name, compress && name != FreezerHashTable)
+		if err != nil {
+			freezer.Close()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	if err := freezer.repair(); err != nil {
+		freezer.Close()
+		return nil, err
+	}
+	return freezer, nil
+}
+
+func (f *Freezer) repair() error {
+	min := ^uint64(0)
+	for _, table := range f.tables {
+		if items := table.Items(); items < min {
+			min = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown ancient table %q", kind)
+	}
+	if number >= f.Ancients() {
+		return nil, errOutOfBounds
+	}
+	return table.Retrieve(number)
+}
+
+// AppendAncient adds the data of the next canonical block to every table. The
+// new item only becomes visible once all tables have accepted it.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := f.Ancients(); number != frozen {
+		return fmt.Errorf("%v: have %d items, appending %d", errOutOrderWrite, frozen, number)
+	}
+	items := map[string][]byte{
+		FreezerHashTable:       hash,
+		FreezerHeaderTable:     header,
+		FreezerBodiesTable:     body,
+		FreezerReceiptTable:    receipts,
+		FreezerDifficultyTable: td,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].Append(number, items[name]); err != nil {
+			for _, table := range f.tables {
+				table.truncate(number)
+			}
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, number+1)
+	return nil
+}
+
+// TruncateAncients discards all items from the given number onwards, hiding them
+// from readers before the tables are cut back.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if items >= f.Ancients() {
+		return nil
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Freezer) Sync() error {
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
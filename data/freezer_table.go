@@ -0,0 +1,238 @@
+package epvdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+)
+
+const indexEntrySize = 8
+
+var (
+	errClosed        = errors.New("closed")
+	errOutOfBounds   = errors.New("out of bounds")
+	errOutOrderWrite = errors.New("out of order write")
+)
+
+// freezerTable is a single append-only data file paired with an index file
+// holding the end offset of every item. The first index entry is always zero,
+// so item i spans the data between index entries i and i+1.
+type freezerTable struct {
+	name     string
+	compress bool
+
+	index *os.File
+	data  *os.File
+
+	items uint64
+	size  uint64
+
+	lock sync.RWMutex
+}
+
+func newFreezerTable(dir, name string, compress bool) (*freezerTable, error) {
+	var (
+		rawIndex  = filepath.Join(dir, name+".ridx")
+		compIndex = filepath.Join(dir, name+".cidx")
+	)
+	if _, err := os.Stat(compIndex); err == nil {
+		compress = true
+	} else if _, err := os.Stat(rawIndex); err == nil {
+		compress = false
+	}
+	idxName, datName := rawIndex, filepath.Join(dir, name+".rdat")
+	if compress {
+		idxName, datName = compIndex, filepath.Join(dir, name+".cdat")
+	}
+	index, err := os.OpenFile(idxName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(datName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &freezerTable{name: name, compress: compress, index: index, data: data}
+	if err := t.repair(); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *freezerTable) offset(item uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// repair brings the index and data files back in sync after a crash, dropping
+// any partially written trailing items.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.Size() == 0 {
+		if _, err := t.index.WriteAt(make([]byte, indexEntrySize), 0); err != nil {
+			return err
+		}
+		stat, err = t.index.Stat()
+		if err != nil {
+			return err
+		}
+	}
+	entries := uint64(stat.Size()) / indexEntrySize
+	if dstat, err := t.data.Stat(); err != nil {
+		return err
+	} else {
+		t.size = uint64(dstat.Size())
+	}
+	for entries > 1 {
+		end, err := t.offset(entries - 1)
+		if err != nil {
+			return err
+		}
+		if end <= t.size {
+			break
+		}
+		entries--
+	}
+	end, err := t.offset(entries - 1)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(entries * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.size, t.items = end, entries-1
+	return nil
+}
+
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if item != t.items {
+		return fmt.Errorf("%v: table %s has %d items, appending %d", errOutOrderWrite, t.name, t.items, item)
+	}
+	if t.compress {
+		blob = snappy.Encode(nil, blob)
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(entry[:], int64((item+1)*indexEntrySize)); err != nil {
+		return err
+	}
+	t.size += uint64(len(blob))
+	atomic.StoreUint64(&t.items, item+1)
+	return nil
+}
+
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	if t.compress {
+		return snappy.Decode(nil, blob)
+	}
+	return blob, nil
+}
+
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if items >= t.items {
+		return nil
+	}
+	end, err := t.offset(items)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64((items + 1) * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.size = end
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.index = nil
+	}
+	if t.data != nil {
+		if err := t.data.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.data = nil
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ type Database interface {
 
 type Batch interface {
 	Putter
+	Delete(key []byte) error
 	ValueSize() int 
 	Write() error
 
@@ -252,9 +252,12 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		return stateDb.RawDump(), nil
 	}
 	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		block = api.epv.blockchain.CurrentBlock()
-	} else {
+	case rpc.FinalizedBlockNumber:
+		block = api.epv.blockchain.FinalizedBlock()
+	default:
 		block = api.epv.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
@@ -16,6 +16,7 @@ import (
 	"github.com/epvchain/go-epvchain/epv/downloader"
 	"github.com/epvchain/go-epvchain/epv/gasprice"
 	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/local/epvapi"
 	"github.com/epvchain/go-epvchain/notice"
 	"github.com/epvchain/go-epvchain/content"
 	"github.com/epvchain/go-epvchain/remote"
@@ -49,6 +50,12 @@ func (b *EPVApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.epv.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		if block := b.epv.blockchain.FinalizedBlock(); block != nil {
+			return block.Header(), nil
+		}
+		return nil, nil
+	}
 	return b.epv.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -62,6 +69,9 @@ func (b *EPVApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.epv.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.epv.blockchain.FinalizedBlock(), nil
+	}
 	return b.epv.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
@@ -92,11 +102,12 @@ func (b *EPVApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.epv.blockchain.GetTdByHash(blockHash)
 }
 
-func (b *EPVApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+func (b *EPVApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, blockOverrides *epvapi.BlockOverrides) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	vmError := func() error { return nil }
 
 	context := core.NewEVMContext(msg, header, b.epv.BlockChain(), nil)
+	blockOverrides.Apply(&context)
 	return vm.NewEVM(context, state, b.epv.chainConfig, vmCfg), vmError, nil
 }
 
@@ -168,6 +179,10 @@ func (b *EPVApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EPVApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, rewardPercentiles)
+}
+
 func (b *EPVApiBackend) ChainDb() epvdb.Database {
 	return b.epv.ChainDb()
 }
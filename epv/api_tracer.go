@@ -13,6 +13,7 @@ import (
 
 	"github.com/epvchain/go-epvchain/public"
 	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/public/math"
 	"github.com/epvchain/go-epvchain/kernel"
 	"github.com/epvchain/go-epvchain/kernel/state"
 	"github.com/epvchain/go-epvchain/kernel/types"
@@ -71,6 +72,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.epv.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.epv.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.epv.blockchain.FinalizedBlock()
 	default:
 		from = api.epv.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -79,6 +82,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.epv.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.epv.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.epv.blockchain.FinalizedBlock()
 	default:
 		to = api.epv.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -157,10 +162,10 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 				signer := types.MakeSigner(api.config, task.block.Number())
 
 				for i, tx := range task.block.Transactions() {
-					msg, _ := tx.AsMessage(signer)
+					msg, _ := tx.AsMessage(signer, task.block.BaseFee())
 					vmctx := core.NewEVMContext(msg, task.block.Header(), api.epv.blockchain, nil)
 
-					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
+					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config, vm.Config{})
 					if err != nil {
 						task.results[i] = &txTraceResult{Error: err.Error()}
 						log.Warn("Tracing failed", "hash", tx.Hash(), "block", task.block.NumberU64(), "err", err)
@@ -302,6 +307,8 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.epv.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.epv.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.epv.blockchain.FinalizedBlock()
 	default:
 		block = api.epv.blockchain.GetBlockByNumber(uint64(number))
 	}
@@ -373,10 +380,10 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 			defer pend.Done()
 
 			for task := range jobs {
-				msg, _ := txs[task.index].AsMessage(signer)
+				msg, _ := txs[task.index].AsMessage(signer, block.BaseFee())
 				vmctx := core.NewEVMContext(msg, block.Header(), api.epv.blockchain, nil)
 
-				res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
+				res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config, vm.Config{})
 				if err != nil {
 					results[task.index] = &txTraceResult{Error: err.Error()}
 					continue
@@ -391,7 +398,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 
 		jobs <- &txTraceTask{statedb: statedb.Copy(), index: i}
 
-		msg, _ := tx.AsMessage(signer)
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
 		vmctx := core.NewEVMContext(msg, block.Header(), api.epv.blockchain, nil)
 
 		vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{})
@@ -489,10 +496,62 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 		return nil, err
 	}
 
-	return api.traceTx(ctx, msg, vmctx, statedb, config)
+	return api.traceTx(ctx, msg, vmctx, statedb, config, vm.Config{})
 }
 
-func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
+// TraceCallConfig is the config for TraceCall. It extends TraceConfig with
+// the same overrides epv_call accepts.
+type TraceCallConfig struct {
+	TraceConfig
+	StateOverrides *epvapi.StateOverride
+	BlockOverrides *epvapi.BlockOverrides
+}
+
+// TraceCall traces a call executed on top of the state of the given block
+// without sending it, optionally with some accounts and block context fields
+// overridden.
+func (api *PrivateDebugAPI) TraceCall(ctx context.Context, args epvapi.CallArgs, blockNr rpc.BlockNumber, config *TraceCallConfig) (interface{}, error) {
+
+	var (
+		statedb *state.StateDB
+		header  *types.Header
+		err     error
+	)
+	if blockNr == rpc.PendingBlockNumber {
+		if statedb, header, err = api.epv.ApiBackend.StateAndHeaderByNumber(ctx, blockNr); err != nil {
+			return nil, err
+		}
+	} else {
+		block, _ := api.epv.ApiBackend.BlockByNumber(ctx, blockNr)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", blockNr)
+		}
+		reexec := defaultTraceReexec
+		if config != nil && config.Reexec != nil {
+			reexec = *config.Reexec
+		}
+		if statedb, err = api.computeStateDB(block, reexec); err != nil {
+			return nil, err
+		}
+		header = block.Header()
+	}
+	msg := args.ToMessage(api.epv.AccountManager(), header)
+	statedb.SetBalance(msg.From(), math.MaxBig256)
+
+	vmctx := core.NewEVMContext(msg, header, api.epv.blockchain, nil)
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, msg, vmctx, statedb, traceConfig, vm.Config{NoBaseFee: true})
+}
+
+func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig, vmCfg vm.Config) (interface{}, error) {
 
 	var (
 		tracer vm.Tracer
@@ -508,14 +567,16 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			}
 		}
 
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		if native, ok := tracers.NewNative(*config.Tracer); ok {
+			tracer = native
+		} else if tracer, err = tracers.New(*config.Tracer); err != nil {
 			return nil, err
 		}
 
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			tracer.(tracers.ResultTracer).Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -526,7 +587,8 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
 
-	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: tracer})
+	vmCfg.Debug, vmCfg.Tracer = true, tracer
+	vmenv := vm.NewEVM(vmctx, statedb, api.config, vmCfg)
 
 	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
 	if err != nil {
@@ -542,7 +604,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  epvapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
@@ -569,7 +631,7 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 
 	for idx, tx := range block.Transactions() {
 
-		msg, _ := tx.AsMessage(signer)
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
 		context := core.NewEVMContext(msg, block.Header(), api.epv.blockchain, nil)
 		if idx == txIndex {
 			return msg, context, statedb, nil
@@ -17,10 +17,12 @@ import (
 	"github.com/epvchain/go-epvchain/agreement/epvhash"
 	"github.com/epvchain/go-epvchain/kernel"
 	"github.com/epvchain/go-epvchain/kernel/bloombits"
+	"github.com/epvchain/go-epvchain/kernel/state/pruner"
 	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/kernel/vm"
 	"github.com/epvchain/go-epvchain/epv/downloader"
 	"github.com/epvchain/go-epvchain/epv/filters"
+	"github.com/epvchain/go-epvchain/epv/finality"
 	"github.com/epvchain/go-epvchain/epv/gasprice"
 	"github.com/epvchain/go-epvchain/data"
 	"github.com/epvchain/go-epvchain/notice"
@@ -52,6 +54,7 @@ type EPVchain struct {
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
 	lesServer       LesServer
+	finality        *finality.Gadget
 
 	chainDb epvdb.Database 
 
@@ -90,6 +93,9 @@ func New(ctx *node.ServiceContext, config *Config) (*EPVchain, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := pruner.RecoverPruning(ctx.ResolvePath(""), chainDb); err != nil {
+		return nil, err
+	}
 	stopDbUpgrade := upgradeDeduplicateData(chainDb)
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
@@ -124,7 +130,7 @@ func New(ctx *node.ServiceContext, config *Config) (*EPVchain, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, Snapshot: config.Snapshot, AncientThreshold: config.AncientThreshold}
 	)
 	epv.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, epv.chainConfig, epv.engine, vmConfig)
 	if err != nil {
@@ -133,7 +139,9 @@ func New(ctx *node.ServiceContext, config *Config) (*EPVchain, error) {
 
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		epv.blockchain.SetHead(compat.RewindTo)
+		if err := epv.blockchain.SetHead(compat.RewindTo); err != nil {
+			return nil, err
+		}
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	epv.bloomIndexer.Start(epv.blockchain)
@@ -146,6 +154,9 @@ func New(ctx *node.ServiceContext, config *Config) (*EPVchain, error) {
 	if epv.protocolManager, err = NewProtocolManager(epv.chainConfig, config.SyncMode, config.NetworkId, epv.eventMux, epv.txPool, epv.engine, epv.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	if dpos, ok := epv.engine.(*epvdpos.DPos); ok {
+		epv.finality = finality.New(epv.blockchain, dpos, chainDb)
+	}
 	epv.miner = miner.New(epv, epv.chainConfig, epv.EventMux(), epv.engine)
 	epv.miner.SetExtra(makeExtraData(config.ExtraData))
 
@@ -183,6 +194,11 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (epvdb.Data
 	}
 	if db, ok := db.(*epvdb.LDBDatabase); ok {
 		db.Meter("epv/db/chaindata/")
+
+		if err := core.OpenAncients(db, config.AncientThreshold > 0, config.AncientCompress); err != nil {
+			db.Close()
+			return nil, err
+		}
 	}
 	return db, nil
 }
@@ -310,7 +326,11 @@ func (s *EPVchain) StartMining(local bool) error {
 		log.Error("Cannot start mining without epvcbase", "err", err)
 		return fmt.Errorf("epvcbase missing: %v", err)
 	}
-	if dpos, ok := s.engine.(*epvdpos.DPos); ok {
+	if dpos, ok := s.engine.(*epvdpos.DPos); ok && s.config.DPosSigner != "" {
+		remote := epvdpos.NewRemoteSigner(s.config.DPosSigner, s.config.DPosSignerTimeout)
+		log.Info("Delegating block signing to remote signer", "endpoint", remote.Endpoint(), "signer", eb)
+		dpos.AuthorizeRemote(eb, remote)
+	} else if ok {
 		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 		if wallet == nil || err != nil {
 			log.Error("EPVCbase account unavailable locally", "err", err)
@@ -342,10 +362,14 @@ func (s *EPVchain) NetVersion() uint64                 { return s.networkId }
 func (s *EPVchain) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
 func (s *EPVchain) Protocols() []p2p.Protocol {
+	protos := append([]p2p.Protocol{}, s.protocolManager.SubProtocols...)
+	if s.finality != nil {
+		protos = append(protos, s.finality.Protocols()...)
+	}
 	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+		return protos
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protos, s.lesServer.Protocols()...)
 }
 
 func (s *EPVchain) Start(srvr *p2p.Server) error {
@@ -363,6 +387,9 @@ func (s *EPVchain) Start(srvr *p2p.Server) error {
 	}
 
 	s.protocolManager.Start(maxPeers)
+	if s.finality != nil {
+		s.finality.Start()
+	}
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
@@ -376,6 +403,9 @@ func (s *EPVchain) Stop() error {
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
+	if s.finality != nil {
+		s.finality.Stop()
+	}
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
@@ -64,6 +64,10 @@ type Config struct {
 	NetworkId uint64 
 	SyncMode  downloader.SyncMode
 	NoPruning bool
+	Snapshot  bool
+
+	AncientThreshold uint64 `toml:",omitempty"`
+	AncientCompress  bool   `toml:",omitempty"`
 
 	LightServ  int `toml:",omitempty"` 
 	LightPeers int `toml:",omitempty"` 
@@ -79,6 +83,9 @@ type Config struct {
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	DPosSigner        string        `toml:",omitempty"`
+	DPosSignerTimeout time.Duration `toml:",omitempty"`
+
 	EPVhash epvhash.Config
 
 	TxPool core.TxPoolConfig
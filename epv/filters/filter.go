@@ -79,6 +79,18 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
+	if f.begin == rpc.FinalizedBlockNumber.Int64() || f.end == rpc.FinalizedBlockNumber.Int64() {
+		header, _ := f.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+		if header == nil {
+			return nil, nil
+		}
+		if f.begin == rpc.FinalizedBlockNumber.Int64() {
+			f.begin = header.Number.Int64()
+		}
+		if f.end == rpc.FinalizedBlockNumber.Int64() {
+			f.end = header.Number.Int64()
+		}
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
@@ -0,0 +1,413 @@
+package finality
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/agreement/epvdpos"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/notice"
+	"github.com/epvchain/go-epvchain/peer"
+	"github.com/epvchain/go-epvchain/process"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	chainHeadChanSize = 10
+	maxKnownVotes     = 16384
+
+	maxQueuedVotes = 128 // Maximum number of votes queued for propagation to a peer
+
+	maxFutureVotes    = 1024 // Maximum number of votes for unknown blocks kept for replay
+	maxFutureDistance = 64   // Maximum distance from the head a vote for an unknown block is kept at
+)
+
+// lastVotePrefix + signer -> RLP(lastVote), the latest block the signer
+// precommitted, kept so it never votes for a conflicting block after a reorg
+// or restart.
+var lastVotePrefix = []byte("finality-last-vote-")
+
+type lastVote struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+type tally struct {
+	number  uint64
+	signers map[common.Address]struct{}
+	quorum  bool // Whether the signers reached quorum, kept until finalization succeeds
+}
+
+// futureVote is a vote for a block not yet imported, replayed once the chain
+// reaches its number.
+type futureVote struct {
+	vote *Vote
+	from *peer
+}
+
+type peer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	queue chan *Vote    // Queue of votes to propagate to the peer
+	term  chan struct{} // Termination channel to stop the broadcaster
+}
+
+// broadcast sends the queued votes to the peer until it disconnects or a
+// send fails.
+func (p *peer) broadcast() {
+	for {
+		select {
+		case vote := <-p.queue:
+			if err := p2p.Send(p.rw, VoteMsg, vote); err != nil {
+				log.Debug("Failed to propagate precommit vote", "peer", p.ID(), "err", err)
+				return
+			}
+		case <-p.term:
+			return
+		}
+	}
+}
+
+// AsyncSendVote queues a vote for propagation to the peer, dropping it if
+// the queue is full.
+func (p *peer) AsyncSendVote(vote *Vote) {
+	select {
+	case p.queue <- vote:
+	default:
+		log.Debug("Dropping precommit vote propagation", "peer", p.ID(), "number", vote.Number, "hash", vote.Hash)
+	}
+}
+
+type Gadget struct {
+	chain  *core.BlockChain
+	engine *epvdpos.DPos
+	db     epvdb.Database
+
+	peers   map[*peer]struct{}
+	known   *lru.Cache
+	tallies map[common.Hash]*tally
+	future  map[uint64]map[common.Hash]*futureVote // Votes for unknown blocks, by number and vote ID
+	pending int                                    // Number of votes in future
+	lock    sync.Mutex
+
+	headCh  chan core.ChainHeadEvent
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func New(chain *core.BlockChain, engine *epvdpos.DPos, db epvdb.Database) *Gadget {
+	known, _ := lru.New(maxKnownVotes)
+	return &Gadget{
+		chain:   chain,
+		engine:  engine,
+		db:      db,
+		peers:   make(map[*peer]struct{}),
+		known:   known,
+		tallies: make(map[common.Hash]*tally),
+		future:  make(map[uint64]map[common.Hash]*futureVote),
+		headCh:  make(chan core.ChainHeadEvent, chainHeadChanSize),
+		quit:    make(chan struct{}),
+	}
+}
+
+func (g *Gadget) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    ProtocolName,
+		Version: ProtocolVersion,
+		Length:  ProtocolLength,
+		Run:     g.runPeer,
+	}}
+}
+
+func (g *Gadget) Start() {
+	g.headSub = g.chain.SubscribeChainHeadEvent(g.headCh)
+
+	g.wg.Add(1)
+	go g.loop()
+}
+
+func (g *Gadget) Stop() {
+	g.headSub.Unsubscribe()
+	close(g.quit)
+	g.wg.Wait()
+}
+
+func (g *Gadget) loop() {
+	defer g.wg.Done()
+
+	for {
+		select {
+		case ev := <-g.headCh:
+			g.replay(ev.Block.NumberU64())
+			g.retry()
+			g.precommit(ev.Block)
+
+		case <-g.headSub.Err():
+			return
+		case <-g.quit:
+			return
+		}
+	}
+}
+
+func (g *Gadget) precommit(block *types.Block) {
+	signers, err := g.engine.Signers(g.chain, block.Header())
+	if err != nil {
+		return
+	}
+	signer := g.engine.Signer()
+	if !contains(signers, signer) {
+		return
+	}
+	// Never vote at or below the height of an earlier vote for another block,
+	// and persist the vote before it is signed
+	number, hash := block.NumberU64(), block.Hash()
+
+	last, err := g.lastVote(signer)
+	if err != nil {
+		log.Error("Failed to load last precommit vote", "err", err)
+		return
+	}
+	if last != nil && (number < last.Number || (number == last.Number && hash != last.Hash)) {
+		log.Debug("Refusing conflicting precommit vote", "number", number, "hash", hash, "last", last.Number, "lasthash", last.Hash)
+		return
+	}
+	if err := g.storeVote(signer, &lastVote{Number: number, Hash: hash}); err != nil {
+		log.Error("Failed to store precommit vote", "number", number, "hash", hash, "err", err)
+		return
+	}
+	sig, err := g.engine.SignHash(voteHash(number, hash))
+	if err != nil {
+		log.Debug("Failed to sign precommit vote", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	g.handleVote(&Vote{Number: number, Hash: hash, Signature: sig}, nil)
+}
+
+// lastVote retrieves the latest block precommitted by signer, nil if none.
+func (g *Gadget) lastVote(signer common.Address) (*lastVote, error) {
+	blob, err := g.db.Get(append(lastVotePrefix, signer[:]...))
+	if err != nil {
+		return nil, nil
+	}
+	vote := new(lastVote)
+	if err := rlp.DecodeBytes(blob, vote); err != nil {
+		return nil, err
+	}
+	return vote, nil
+}
+
+// storeVote persists the latest block precommitted by signer.
+func (g *Gadget) storeVote(signer common.Address, vote *lastVote) error {
+	blob, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return err
+	}
+	return g.db.Put(append(lastVotePrefix, signer[:]...), blob)
+}
+
+func (g *Gadget) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	pr := &peer{Peer: p, rw: rw, queue: make(chan *Vote, maxQueuedVotes), term: make(chan struct{})}
+	go pr.broadcast()
+	defer close(pr.term)
+
+	g.lock.Lock()
+	g.peers[pr] = struct{}{}
+	g.lock.Unlock()
+
+	defer func() {
+		g.lock.Lock()
+		delete(g.peers, pr)
+		g.lock.Unlock()
+	}()
+	for {
+		if err := g.handleMsg(pr); err != nil {
+			log.Debug("Finality peer handling failed", "peer", p.ID(), "err", err)
+			return err
+		}
+	}
+}
+
+func (g *Gadget) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+
+	if msg.Size > ProtocolMaxMsgSize {
+		return errMsgTooLarge
+	}
+	switch msg.Code {
+	case VoteMsg:
+		var vote Vote
+		if err := msg.Decode(&vote); err != nil {
+			return err
+		}
+		return g.handleVote(&vote, p)
+
+	default:
+		return errInvalidMsgCode
+	}
+}
+
+// handleVote validates a vote and counts it towards the quorum of its block.
+// Votes for blocks not imported yet are kept and replayed on import. A vote
+// is only marked known once accepted, so that it can still be counted and
+// propagated if it arrived too early.
+func (g *Gadget) handleVote(vote *Vote, from *peer) error {
+	id := vote.ID()
+	if g.known.Contains(id) {
+		return nil
+	}
+	if finalized := g.chain.FinalizedBlock(); finalized != nil && vote.Number <= finalized.NumberU64() {
+		return nil
+	}
+	signer, err := vote.Signer()
+	if err != nil {
+		return errInvalidVote
+	}
+	header := g.chain.GetHeader(vote.Hash, vote.Number)
+	if header == nil {
+		g.buffer(id, vote, from)
+		return nil
+	}
+	signers, err := g.engine.Signers(g.chain, header)
+	if err != nil || !contains(signers, signer) {
+		return nil
+	}
+	g.known.Add(id, struct{}{})
+	g.broadcast(vote, from)
+
+	g.lock.Lock()
+	t, ok := g.tallies[vote.Hash]
+	if !ok {
+		t = &tally{number: vote.Number, signers: make(map[common.Address]struct{})}
+		g.tallies[vote.Hash] = t
+	}
+	t.signers[signer] = struct{}{}
+	if 3*len(t.signers) > 2*len(signers) {
+		t.quorum = true
+	}
+	quorum := t.quorum
+	g.lock.Unlock()
+
+	if quorum {
+		g.finalize(vote.Hash, vote.Number)
+	}
+	return nil
+}
+
+// finalize marks the block finalized and drops the tallies it supersedes. If
+// the block is not canonical yet, the tally is kept and retried on the next
+// chain head.
+func (g *Gadget) finalize(hash common.Hash, number uint64) bool {
+	if err := g.chain.SetFinalized(hash, number); err != nil {
+		log.Debug("Failed to finalize block", "number", number, "hash", hash, "err", err)
+		return false
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for h, other := range g.tallies {
+		if other.number <= number {
+			delete(g.tallies, h)
+		}
+	}
+	return true
+}
+
+// retry attempts again to finalize the blocks whose votes reached quorum,
+// the highest first, until one succeeds.
+func (g *Gadget) retry() {
+	var candidates []common.Hash
+
+	g.lock.Lock()
+	numbers := make(map[common.Hash]uint64)
+	for hash, t := range g.tallies {
+		if t.quorum {
+			candidates = append(candidates, hash)
+			numbers[hash] = t.number
+		}
+	}
+	g.lock.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool { return numbers[candidates[i]] > numbers[candidates[j]] })
+	for _, hash := range candidates {
+		if g.finalize(hash, numbers[hash]) {
+			return
+		}
+	}
+}
+
+// buffer keeps a vote for a block that is not imported yet. Votes too far
+// from the head, or beyond the buffer's capacity, are dropped.
+func (g *Gadget) buffer(id common.Hash, vote *Vote, from *peer) {
+	head := g.chain.CurrentHeader().Number.Uint64()
+	if vote.Number > head+maxFutureDistance || vote.Number+maxFutureDistance < head {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	votes := g.future[vote.Number]
+	if _, ok := votes[id]; ok || g.pending >= maxFutureVotes {
+		return
+	}
+	if votes == nil {
+		votes = make(map[common.Hash]*futureVote)
+		g.future[vote.Number] = votes
+	}
+	votes[id] = &futureVote{vote: vote, from: from}
+	g.pending++
+}
+
+// replay handles again the buffered votes for blocks up to number. Those whose
+// block is still unknown are buffered anew.
+func (g *Gadget) replay(number uint64) {
+	var votes []*futureVote
+
+	g.lock.Lock()
+	for n, pending := range g.future {
+		if n > number {
+			continue
+		}
+		for _, vote := range pending {
+			votes = append(votes, vote)
+		}
+		g.pending -= len(pending)
+		delete(g.future, n)
+	}
+	g.lock.Unlock()
+
+	for _, v := range votes {
+		g.handleVote(v.vote, v.from)
+	}
+}
+
+func (g *Gadget) broadcast(vote *Vote, from *peer) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for p := range g.peers {
+		if p == from {
+			continue
+		}
+		p.AsyncSendVote(vote)
+	}
+}
+
+func contains(signers []common.Address, signer common.Address) bool {
+	for _, s := range signers {
+		if s == signer {
+			return true
+		}
+	}
+	return false
+}
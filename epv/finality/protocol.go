@@ -0,0 +1,58 @@
+package finality
+
+import (
+	"errors"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/code/sha3"
+	"github.com/epvchain/go-epvchain/process"
+)
+
+const (
+	ProtocolName    = "dpf"
+	ProtocolVersion = 1
+	ProtocolLength  = 1
+
+	ProtocolMaxMsgSize = 4 * 1024
+)
+
+const (
+	VoteMsg = 0x00
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too large")
+	errInvalidMsgCode = errors.New("invalid message code")
+	errInvalidVote    = errors.New("invalid precommit vote")
+)
+
+type Vote struct {
+	Number    uint64
+	Hash      common.Hash
+	Signature []byte
+}
+
+func voteHash(number uint64, hash common.Hash) (h common.Hash) {
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, []interface{}{"precommit", number, hash})
+	hasher.Sum(h[:0])
+	return h
+}
+
+func (v *Vote) ID() common.Hash {
+	return crypto.Keccak256Hash(v.Hash[:], v.Signature)
+}
+
+func (v *Vote) Signer() (common.Address, error) {
+	if len(v.Signature) != 65 {
+		return common.Address{}, errInvalidVote
+	}
+	pubkey, err := crypto.Ecrecover(voteHash(v.Number, v.Hash).Bytes(), v.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
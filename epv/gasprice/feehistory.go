@@ -0,0 +1,142 @@
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/epvchain/go-epvchain/agreement/misc"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/remote"
+)
+
+// maxFeeHistory is the most blocks a single fee history query may cover.
+const maxFeeHistory = 1024
+
+var (
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+)
+
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+type sortGasAndReward []txGasAndReward
+
+func (s sortGasAndReward) Len() int           { return len(s) }
+func (s sortGasAndReward) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s sortGasAndReward) Less(i, j int) bool { return s[i].reward.Cmp(s[j].reward) < 0 }
+
+// FeeHistory returns the base fees and gas usage ratios of up to blocks
+// blocks ending with lastBlock, together with the tips paid at the given
+// percentiles of gas used in each of them. The base fee list has an extra
+// entry for the block after lastBlock. Blocks before the base fee fork
+// report a zero base fee.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	if blocks < 1 {
+		return new(big.Int), nil, nil, nil, nil
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, nil, nil, nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, nil, nil, nil, fmt.Errorf("%v: #%d:%f > #%d:%f", errInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	if lastBlock == rpc.PendingBlockNumber {
+		lastBlock = rpc.LatestBlockNumber
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if head == nil {
+		if err == nil {
+			err = errRequestBeyondHead
+		}
+		return nil, nil, nil, nil, err
+	}
+	last := head.Number.Uint64()
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	var (
+		config   = gpo.backend.ChainConfig()
+		reward   = make([][]*big.Int, blocks)
+		baseFee  = make([]*big.Int, blocks+1)
+		gasRatio = make([]float64, blocks)
+		header   *types.Header
+	)
+	for i := 0; i < blocks; i++ {
+		number := rpc.BlockNumber(oldest + uint64(i))
+		if len(rewardPercentiles) > 0 {
+			block, err := gpo.backend.BlockByNumber(ctx, number)
+			if block == nil {
+				return nil, nil, nil, nil, err
+			}
+			receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			header = block.Header()
+			reward[i] = blockRewards(block, receipts, rewardPercentiles)
+		} else {
+			if header, err = gpo.backend.HeaderByNumber(ctx, number); header == nil {
+				return nil, nil, nil, nil, err
+			}
+		}
+		baseFee[i] = new(big.Int)
+		if header.BaseFee != nil {
+			baseFee[i].Set(header.BaseFee)
+		}
+		if header.GasLimit > 0 {
+			gasRatio[i] = float64(header.GasUsed) / float64(header.GasLimit)
+		}
+	}
+	baseFee[blocks] = new(big.Int)
+	if config.IsBaseFee(new(big.Int).Add(header.Number, big.NewInt(1))) {
+		baseFee[blocks] = misc.CalcBaseFee(config, header)
+	}
+	if len(rewardPercentiles) == 0 {
+		reward = nil
+	}
+	return new(big.Int).SetUint64(oldest), reward, baseFee, gasRatio, nil
+}
+
+// blockRewards returns the tips paid at the given percentiles of the gas used
+// in the block, weighting every transaction by the gas it used.
+func blockRewards(block *types.Block, receipts types.Receipts, percentiles []float64) []*big.Int {
+	reward := make([]*big.Int, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 || len(receipts) != len(txs) {
+		for i := range reward {
+			reward[i] = new(big.Int)
+		}
+		return reward
+	}
+	sorted := make(sortGasAndReward, len(txs))
+	for i, tx := range txs {
+		tip, _ := tx.EffectiveGasTip(block.BaseFee())
+		sorted[i] = txGasAndReward{gasUsed: receipts[i].GasUsed, reward: tip}
+	}
+	sort.Sort(sorted)
+
+	var txIndex int
+	sumGasUsed := sorted[0].gasUsed
+	for i, p := range percentiles {
+		threshold := uint64(float64(block.GasUsed()) * p / 100)
+		for sumGasUsed < threshold && txIndex < len(txs)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+		reward[i] = sorted[txIndex].reward
+	}
+	return reward
+}
@@ -55,13 +55,25 @@ func NewOracle(backend epvapi.Backend, params Config) *Oracle {
 	}
 }
 
+// SuggestPrice returns a gas price for legacy transactions: the suggested tip
+// on top of the base fee of the latest block, if it has one.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	tip, err := gpo.suggestTip(ctx, head)
+	if head.BaseFee != nil {
+		tip = new(big.Int).Add(tip, head.BaseFee)
+	}
+	return tip, err
+}
+
+// suggestTip returns the percentile of the lowest tips paid in recent blocks.
+// For blocks without a base fee the tip is the full gas price.
+func (gpo *Oracle) suggestTip(ctx context.Context, head *types.Header) (*big.Int, error) {
 	gpo.cacheLock.RLock()
 	lastHead := gpo.lastHead
 	lastPrice := gpo.lastPrice
 	gpo.cacheLock.RUnlock()
 
-	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	headHash := head.Hash()
 	if headHash == lastHead {
 		return lastPrice, nil
@@ -132,11 +144,18 @@ type getBlockPricesResult struct {
 	err   error
 }
 
-type transactionsByGasPrice []*types.Transaction
+type transactionsByGasTip struct {
+	txs     []*types.Transaction
+	baseFee *big.Int
+}
 
-func (t transactionsByGasPrice) Len() int           { return len(t) }
-func (t transactionsByGasPrice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
-func (t transactionsByGasPrice) Less(i, j int) bool { return t[i].GasPrice().Cmp(t[j].GasPrice()) < 0 }
+func (t transactionsByGasTip) Len() int      { return len(t.txs) }
+func (t transactionsByGasTip) Swap(i, j int) { t.txs[i], t.txs[j] = t.txs[j], t.txs[i] }
+func (t transactionsByGasTip) Less(i, j int) bool {
+	tipi, _ := t.txs[i].EffectiveGasTip(t.baseFee)
+	tipj, _ := t.txs[j].EffectiveGasTip(t.baseFee)
+	return tipi.Cmp(tipj) < 0
+}
 
 func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, blockNum uint64, ch chan getBlockPricesResult) {
 	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNum))
@@ -148,12 +167,14 @@ func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, bloc
 	blockTxs := block.Transactions()
 	txs := make([]*types.Transaction, len(blockTxs))
 	copy(txs, blockTxs)
-	sort.Sort(transactionsByGasPrice(txs))
+	baseFee := block.BaseFee()
+	sort.Sort(transactionsByGasTip{txs, baseFee})
 
 	for _, tx := range txs {
 		sender, err := types.Sender(signer, tx)
 		if err == nil && sender != block.Coinbase() {
-			ch <- getBlockPricesResult{tx.GasPrice(), nil}
+			tip, _ := tx.EffectiveGasTip(baseFee)
+			ch <- getBlockPricesResult{tip, nil}
 			return
 		}
 	}
@@ -3,6 +3,7 @@ package epv
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/epvchain/go-epvchain/public"
 	"github.com/epvchain/go-epvchain/public/hexutil"
@@ -19,6 +20,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
+		Snapshot                bool
+		AncientThreshold        uint64 `toml:",omitempty"`
+		AncientCompress         bool   `toml:",omitempty"`
 		LightServ               int  `toml:",omitempty"`
 		LightPeers              int  `toml:",omitempty"`
 		SkipBcVersionCheck      bool `toml:"-"`
@@ -28,6 +32,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		DPosSigner              string        `toml:",omitempty"`
+		DPosSignerTimeout       time.Duration `toml:",omitempty"`
 		EPVhash                  epvhash.Config
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
@@ -38,6 +44,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.Snapshot = c.Snapshot
+	enc.AncientThreshold = c.AncientThreshold
+	enc.AncientCompress = c.AncientCompress
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
@@ -47,6 +56,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.DPosSigner = c.DPosSigner
+	enc.DPosSignerTimeout = c.DPosSignerTimeout
 	enc.EPVhash = c.EPVhash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
@@ -60,6 +71,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
+		Snapshot                *bool
+		AncientThreshold        *uint64 `toml:",omitempty"`
+		AncientCompress         *bool   `toml:",omitempty"`
 		LightServ               *int  `toml:",omitempty"`
 		LightPeers              *int  `toml:",omitempty"`
 		SkipBcVersionCheck      *bool `toml:"-"`
@@ -69,6 +83,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		DPosSigner              *string        `toml:",omitempty"`
+		DPosSignerTimeout       *time.Duration `toml:",omitempty"`
 		EPVhash                  *epvhash.Config
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
@@ -88,6 +104,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.Snapshot != nil {
+		c.Snapshot = *dec.Snapshot
+	}
+	if dec.AncientThreshold != nil {
+		c.AncientThreshold = *dec.AncientThreshold
+	}
+	if dec.AncientCompress != nil {
+		c.AncientCompress = *dec.AncientCompress
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -115,6 +140,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.DPosSigner != nil {
+		c.DPosSigner = *dec.DPosSigner
+	}
+	if dec.DPosSignerTimeout != nil {
+		c.DPosSignerTimeout = *dec.DPosSignerTimeout
+	}
 	if dec.EPVhash != nil {
 		c.EPVhash = *dec.EPVhash
 	}
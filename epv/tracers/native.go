@@ -0,0 +1,92 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/vm"
+	"github.com/epvchain/go-epvchain/book"
+)
+
+// ResultTracer is a vm.Tracer that produces a JSON result once execution is
+// done and that can be aborted from another goroutine. Both the JavaScript
+// tracers and the native ones implement it.
+type ResultTracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	Stop(err error)
+}
+
+var natives = make(map[string]func() ResultTracer)
+
+// registerNative makes a Go tracer available under the given name, shadowing
+// any JavaScript tracer of the same name.
+func registerNative(name string, ctor func() ResultTracer) {
+	natives[name] = ctor
+}
+
+// NewNative returns a fresh instance of the native tracer with the given
+// name, if there is one.
+func NewNative(name string) (ResultTracer, bool) {
+	ctor, ok := natives[name]
+	if !ok {
+		return nil, false
+	}
+	return ctor(), true
+}
+
+// interrupter implements Stop for the native tracers. Like the JavaScript
+// tracer, a stopped tracer ignores the remaining steps and fails with the
+// reason it was stopped for.
+type interrupter struct {
+	interrupt uint32
+	reason    error
+	err       error
+}
+
+func (it *interrupter) Stop(err error) {
+	it.reason = err
+	atomic.StoreUint32(&it.interrupt, 1)
+}
+
+// tracing reports whether the tracer should still process steps.
+func (it *interrupter) tracing() bool {
+	if it.err != nil {
+		return false
+	}
+	if atomic.LoadUint32(&it.interrupt) > 0 {
+		it.err = it.reason
+		return false
+	}
+	return true
+}
+
+// peekStack returns the n-th item from the top of the stack, or zero if the
+// stack is not that deep.
+func peekStack(stack *vm.Stack, n int) *big.Int {
+	if len(stack.Data()) <= n {
+		return new(big.Int)
+	}
+	return stack.Back(n)
+}
+
+func peekAddress(stack *vm.Stack, n int) common.Address {
+	return common.BigToAddress(peekStack(stack, n))
+}
+
+// sliceMemory copies size bytes of memory starting at offset, or returns nil
+// if the range is out of bounds.
+func sliceMemory(memory *vm.Memory, offset, size uint64) []byte {
+	if end := offset + size; end < offset || uint64(memory.Len()) < end {
+		log.Warn("Tracer accessed out of bound memory", "available", memory.Len(), "offset", offset, "size", size)
+		return nil
+	}
+	return memory.Get(int64(offset), int64(size))
+}
+
+func isPrecompiled(addr common.Address) bool {
+	_, ok := vm.PrecompiledContractsIstanbul[addr]
+	return ok
+}
@@ -0,0 +1,79 @@
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/vm"
+)
+
+func init() {
+	registerNative("4byteTracer", func() ResultTracer { return newFourByteTracer() })
+}
+
+// fourByteTracer is the native version of 4byteTracer. It counts the method
+// selectors and calldata sizes of all calls made by the transaction, keyed
+// as "selector-size".
+type fourByteTracer struct {
+	interrupter
+
+	ids   map[string]int
+	input []byte
+}
+
+func newFourByteTracer() *fourByteTracer {
+	return &fourByteTracer{ids: make(map[string]int)}
+}
+
+func (t *fourByteTracer) store(id []byte, size uint64) {
+	t.ids[fmt.Sprintf("%s-%d", hexutil.Encode(id), size)]++
+}
+
+func (t *fourByteTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.input = input
+	return nil
+}
+
+func (t *fourByteTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if !t.tracing() {
+		return nil
+	}
+	var argsAt int
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		argsAt = 3
+	case vm.DELEGATECALL, vm.STATICCALL:
+		argsAt = 2
+	default:
+		return nil
+	}
+	if isPrecompiled(peekAddress(stack, 1)) {
+		return nil
+	}
+	if size := peekStack(stack, argsAt+1).Uint64(); size >= 4 {
+		t.store(sliceMemory(memory, peekStack(stack, argsAt).Uint64(), 4), size-4)
+	}
+	return nil
+}
+
+func (t *fourByteTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *fourByteTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+func (t *fourByteTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if len(t.input) > 4 {
+		t.store(t.input[:4], uint64(len(t.input)-4))
+	}
+	return json.Marshal(t.ids)
+}
@@ -0,0 +1,253 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/vm"
+)
+
+func init() {
+	registerNative("callTracer", func() ResultTracer { return newCallTracer() })
+}
+
+// CallFrame is a single call in the output of the call tracer. Fields that
+// are not known for a frame are left out, matching the JavaScript version.
+type CallFrame struct {
+	Type    string          `json:"type"`
+	From    *common.Address `json:"from,omitempty"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input   *hexutil.Bytes  `json:"input,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Time    string          `json:"time,omitempty"`
+	Calls   []*CallFrame    `json:"calls,omitempty"`
+
+	gasIn, gasCost uint64
+	outOff, outLen uint64
+	descendedGas   *uint64
+}
+
+func (f *CallFrame) addCall(call *CallFrame) {
+	f.Calls = append(f.Calls, call)
+}
+
+// callTracer is the native version of callTracer, reporting the tree of calls
+// made during a transaction.
+type callTracer struct {
+	interrupter
+
+	callstack []*CallFrame
+	descended bool
+
+	create  bool
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	time    time.Duration
+	ctxErr  error
+}
+
+func newCallTracer() *callTracer {
+	return &callTracer{callstack: []*CallFrame{{}}}
+}
+
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.input, t.gas, t.value = create, from, to, input, gas, value
+	return nil
+}
+
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if !t.tracing() {
+		return nil
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		var (
+			from  = contract.Address()
+			input = hexutil.Bytes(sliceMemory(memory, peekStack(stack, 1).Uint64(), peekStack(stack, 2).Uint64()))
+			value = (*hexutil.Big)(new(big.Int).Set(peekStack(stack, 0)))
+		)
+		t.push(&CallFrame{Type: op.String(), From: &from, Input: &input, Value: value, gasIn: gas, gasCost: cost})
+		return nil
+
+	case vm.SELFDESTRUCT:
+		t.top().addCall(&CallFrame{Type: op.String()})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		to := peekAddress(stack, 1)
+		if isPrecompiled(to) {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		var (
+			from  = contract.Address()
+			input = hexutil.Bytes(sliceMemory(memory, peekStack(stack, 2+off).Uint64(), peekStack(stack, 3+off).Uint64()))
+		)
+		call := &CallFrame{
+			Type:    op.String(),
+			From:    &from,
+			To:      &to,
+			Input:   &input,
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peekStack(stack, 4+off).Uint64(),
+			outLen:  peekStack(stack, 5+off).Uint64(),
+		}
+		if off == 1 {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(peekStack(stack, 2)))
+		}
+		t.push(call)
+		return nil
+	}
+	if t.descended {
+		if depth >= len(t.callstack) {
+			g := gas
+			t.top().descendedGas = &g
+		}
+		t.descended = false
+	}
+	if op == vm.REVERT {
+		t.top().Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		call := t.pop()
+
+		if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+			used := hexutil.Uint64(call.gasIn - call.gasCost - gas)
+			call.GasUsed = &used
+
+			if ret := peekStack(stack, 0); ret.Sign() != 0 {
+				to := common.BigToAddress(ret)
+				code := hexutil.Bytes(env.StateDB.GetCode(to))
+				call.To, call.Output = &to, &code
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else if call.descendedGas != nil {
+			used := hexutil.Uint64(call.gasIn - call.gasCost + *call.descendedGas - gas)
+			call.GasUsed = &used
+
+			if ret := peekStack(stack, 0); ret.Sign() != 0 {
+				output := hexutil.Bytes(sliceMemory(memory, call.outOff, call.outLen))
+				call.Output = &output
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		if call.descendedGas != nil {
+			g := hexutil.Uint64(*call.descendedGas)
+			call.Gas = &g
+		}
+		t.top().addCall(call)
+	}
+	return nil
+}
+
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.tracing() {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault records err on the innermost call and moves the call into its
+// parent, unless the call already failed with a revert.
+func (t *callTracer) fault(err error) {
+	if t.top().Error != "" {
+		return
+	}
+	call := t.pop()
+	call.Error = err.Error()
+
+	if call.descendedGas != nil {
+		g := hexutil.Uint64(*call.descendedGas)
+		call.Gas, call.GasUsed = &g, &g
+	}
+	if len(t.callstack) > 0 {
+		t.top().addCall(call)
+		return
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.output, t.gasUsed, t.time, t.ctxErr = output, gasUsed, d, err
+	return nil
+}
+
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	var (
+		typ     = vm.CALL.String()
+		from    = t.from
+		to      = t.to
+		gas     = hexutil.Uint64(t.gas)
+		gasUsed = hexutil.Uint64(t.gasUsed)
+		input   = hexutil.Bytes(t.input)
+		output  = hexutil.Bytes(t.output)
+		value   = new(big.Int)
+	)
+	if t.create {
+		typ = vm.CREATE.String()
+	}
+	if t.value != nil {
+		value.Set(t.value)
+	}
+	result := &CallFrame{
+		Type:    typ,
+		From:    &from,
+		To:      &to,
+		Value:   (*hexutil.Big)(value),
+		Gas:     &gas,
+		GasUsed: &gasUsed,
+		Input:   &input,
+		Output:  &output,
+		Time:    t.time.String(),
+		Calls:   t.callstack[0].Calls,
+		Error:   t.callstack[0].Error,
+	}
+	if result.Error == "" && t.ctxErr != nil {
+		result.Error = t.ctxErr.Error()
+	}
+	if result.Error != "" {
+		result.Output = nil
+	}
+	return json.Marshal(result)
+}
+
+func (t *callTracer) top() *CallFrame {
+	return t.callstack[len(t.callstack)-1]
+}
+
+func (t *callTracer) push(call *CallFrame) {
+	t.callstack = append(t.callstack, call)
+	t.descended = true
+}
+
+func (t *callTracer) pop() *CallFrame {
+	call := t.top()
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	return call
+}
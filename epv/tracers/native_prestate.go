@@ -0,0 +1,140 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/vm"
+	"github.com/epvchain/go-epvchain/code"
+)
+
+func init() {
+	registerNative("prestateTracer", func() ResultTracer { return newPrestateTracer() })
+}
+
+// PrestateAccount is the state of an account in the output of the prestate
+// tracer.
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   int64                       `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateTracer is the native version of prestateTracer, reporting the state
+// of every account and storage slot the transaction touched as it was before
+// the transaction ran.
+type prestateTracer struct {
+	interrupter
+
+	prestate map[common.Address]*PrestateAccount
+	db       vm.StateDB
+
+	create   bool
+	from, to common.Address
+	value    *big.Int
+}
+
+func newPrestateTracer() *prestateTracer {
+	return &prestateTracer{}
+}
+
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &PrestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   int64(t.db.GetNonce(addr)),
+		Code:    t.db.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	storage := t.prestate[addr].Storage
+	if _, ok := storage[key]; ok {
+		return
+	}
+	if val := t.db.GetState(addr, key); val != (common.Hash{}) {
+		storage[key] = val
+	}
+}
+
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if !t.tracing() {
+		return nil
+	}
+	if t.prestate == nil {
+		t.prestate = make(map[common.Address]*PrestateAccount)
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.EXTCODEHASH, vm.BALANCE:
+		t.lookupAccount(peekAddress(stack, 0))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+	case vm.CREATE2:
+		var (
+			salt = common.BigToHash(peekStack(stack, 3))
+			init = sliceMemory(memory, peekStack(stack, 1).Uint64(), peekStack(stack, 2).Uint64())
+		)
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(init)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(peekAddress(stack, 1))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(peekStack(stack, 0)))
+	}
+	return nil
+}
+
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult rolls the sender and recipient back to their state before the
+// value transfer and nonce increment of the transaction. Transactions that
+// ran no code at all have no recorded state.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.prestate == nil {
+		return json.Marshal(map[common.Address]*PrestateAccount{})
+	}
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	value := new(big.Int)
+	if t.value != nil {
+		value.Set(t.value)
+	}
+	var (
+		fromBal = new(big.Int).Add(t.prestate[t.from].Balance.ToInt(), value)
+		toBal   = new(big.Int).Sub(t.prestate[t.to].Balance.ToInt(), value)
+	)
+	t.prestate[t.to].Balance = (*hexutil.Big)(toBal)
+	t.prestate[t.from].Balance = (*hexutil.Big)(fromBal)
+	t.prestate[t.from].Nonce--
+
+	if t.create {
+		delete(t.prestate, t.to)
+	}
+	return json.Marshal(t.prestate)
+}
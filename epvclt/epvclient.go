@@ -12,10 +12,11 @@ import (
 	"github.com/epvchain/go-epvchain/public"
 	"github.com/epvchain/go-epvchain/public/hexutil"
 	"github.com/epvchain/go-epvchain/kernel/types"
-	"github.com/epvchain/go-epvchain/process"
 	"github.com/epvchain/go-epvchain/remote"
 )
 
+var FinalizedBlockNumber = big.NewInt(int64(rpc.FinalizedBlockNumber))
+
 type Client struct {
 	c *rpc.Client
 }
@@ -127,6 +128,14 @@ func (ec *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.H
 	return head, err
 }
 
+func (ec *Client) FinalizedHeader(ctx context.Context) (*types.Header, error) {
+	return ec.HeaderByNumber(ctx, FinalizedBlockNumber)
+}
+
+func (ec *Client) FinalizedBlock(ctx context.Context) (*types.Block, error) {
+	return ec.BlockByNumber(ctx, FinalizedBlockNumber)
+}
+
 type rpcTransaction struct {
 	tx *types.Transaction
 	txExtraInfo
@@ -213,6 +222,9 @@ func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
 	}
+	if number.Cmp(FinalizedBlockNumber) == 0 {
+		return "finalized"
+	}
 	return hexutil.EncodeBig(number)
 }
 
@@ -366,6 +378,39 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+type feeHistoryResultMarshaling struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory retrieves the fee market history of up to blockCount blocks
+// ending with lastBlock, or the latest block if lastBlock is nil.
+func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*epvchain.FeeHistory, error) {
+	var res feeHistoryResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "epv_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
+		return nil, err
+	}
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+	return &epvchain.FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
 func (ec *Client) EstimateGas(ctx context.Context, msg epvchain.CallMsg) (uint64, error) {
 	var hex hexutil.Uint64
 	err := ec.c.CallContext(ctx, &hex, "epv_estimateGas", toCallArg(msg))
@@ -376,7 +421,7 @@ func (ec *Client) EstimateGas(ctx context.Context, msg epvchain.CallMsg) (uint64
 }
 
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -400,5 +445,14 @@ func toCallArg(msg epvchain.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
 	return arg
 }
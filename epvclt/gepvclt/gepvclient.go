@@ -0,0 +1,277 @@
+// Package gepvclient provides typed RPC clients for the node specific dpos,
+// admin, debug and txpool namespaces of go-epvchain.
+package gepvclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/agreement/epvdpos"
+	"github.com/epvchain/go-epvchain/epv"
+	"github.com/epvchain/go-epvchain/epv/tracers"
+	"github.com/epvchain/go-epvchain/local/epvapi"
+	"github.com/epvchain/go-epvchain/peer"
+	"github.com/epvchain/go-epvchain/remote"
+)
+
+// Client is a wrapper around rpc.Client for the APIs that are not part of
+// the epv namespace.
+type Client struct {
+	c *rpc.Client
+}
+
+// New creates a client that uses the given RPC client.
+func New(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// TxTraceResult is the trace of a single transaction, holding either the
+// tracer output or the error tracing failed with.
+type TxTraceResult struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// ExecutionResult is the output of the default struct logger.
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes is a single step of the default struct logger. The step error
+// is kept raw, as the node encodes it as an opaque object.
+type StructLogRes struct {
+	epvapi.StructLogRes
+	Error json.RawMessage `json:"error,omitempty"`
+}
+
+// BlockTraceResult is the trace of a block as streamed by TraceChain.
+type BlockTraceResult struct {
+	Block  hexutil.Uint64   `json:"block"`
+	Hash   common.Hash      `json:"hash"`
+	Traces []*TxTraceResult `json:"traces"`
+}
+
+// Archive returns the DPoS voting snapshot at the given block, or at the
+// latest block if number is nil.
+func (gc *Client) Archive(ctx context.Context, number *big.Int) (*epvdpos.Archive, error) {
+	var archive *epvdpos.Archive
+	if err := gc.c.CallContext(ctx, &archive, "dpos_getArchive", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if archive == nil {
+		return nil, epvchain.NotFound
+	}
+	return archive, nil
+}
+
+// ArchiveAtHash returns the DPoS voting snapshot at the block with the given hash.
+func (gc *Client) ArchiveAtHash(ctx context.Context, hash common.Hash) (*epvdpos.Archive, error) {
+	var archive *epvdpos.Archive
+	if err := gc.c.CallContext(ctx, &archive, "dpos_getArchiveAtHash", hash); err != nil {
+		return nil, err
+	}
+	if archive == nil {
+		return nil, epvchain.NotFound
+	}
+	return archive, nil
+}
+
+// Signers returns the authorized signers at the given block, or at the
+// latest block if number is nil.
+func (gc *Client) Signers(ctx context.Context, number *big.Int) ([]common.Address, error) {
+	var signers []common.Address
+	err := gc.c.CallContext(ctx, &signers, "dpos_getSigners", toBlockNumArg(number))
+	return signers, err
+}
+
+// SignersAtHash returns the authorized signers at the block with the given hash.
+func (gc *Client) SignersAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var signers []common.Address
+	err := gc.c.CallContext(ctx, &signers, "dpos_getSignersAtHash", hash)
+	return signers, err
+}
+
+// InturnSigner returns the signer expected to seal the given block, or the
+// next block if number is nil.
+func (gc *Client) InturnSigner(ctx context.Context, number *big.Int) (common.Address, error) {
+	var signer common.Address
+	err := gc.c.CallContext(ctx, &signer, "dpos_getInturnSigner", toBlockNumArg(number))
+	return signer, err
+}
+
+// Candidates returns the staked signer candidates at the given block, or at
+// the latest block if number is nil.
+func (gc *Client) Candidates(ctx context.Context, number *big.Int) ([]epvdpos.Candidate, error) {
+	var candidates []epvdpos.Candidate
+	err := gc.c.CallContext(ctx, &candidates, "dpos_getCandidates", toBlockNumArg(number))
+	return candidates, err
+}
+
+// SignerStats returns the block production of every signer between the
+// from and to blocks, nil meaning genesis and the latest block respectively.
+func (gc *Client) SignerStats(ctx context.Context, from, to *big.Int) (map[common.Address]*epvdpos.SignerActivity, error) {
+	span := make(map[string]string)
+	if from != nil {
+		span["fromBlock"] = toBlockNumArg(from)
+	}
+	if to != nil {
+		span["toBlock"] = toBlockNumArg(to)
+	}
+	var stats map[common.Address]*epvdpos.SignerActivity
+	err := gc.c.CallContext(ctx, &stats, "dpos_getSignerStats", span)
+	return stats, err
+}
+
+// Evidence returns the recorded double signing evidence.
+func (gc *Client) Evidence(ctx context.Context) ([]*epvdpos.Evidence, error) {
+	var evidence []*epvdpos.Evidence
+	err := gc.c.CallContext(ctx, &evidence, "dpos_getEvidence")
+	return evidence, err
+}
+
+// Proposals returns the signer proposals the node votes for, true meaning
+// authorization and false a removal.
+func (gc *Client) Proposals(ctx context.Context) (map[common.Address]bool, error) {
+	var proposals map[common.Address]bool
+	err := gc.c.CallContext(ctx, &proposals, "dpos_proposals")
+	return proposals, err
+}
+
+// Propose makes the node vote for authorizing or removing a signer in the
+// blocks it seals.
+func (gc *Client) Propose(ctx context.Context, address common.Address, auth bool) error {
+	return gc.c.CallContext(ctx, nil, "dpos_propose", address, auth)
+}
+
+// Discard drops a pending signer proposal of the node.
+func (gc *Client) Discard(ctx context.Context, address common.Address) error {
+	return gc.c.CallContext(ctx, nil, "dpos_discard", address)
+}
+
+// Peers returns information on the peers the node is connected to.
+func (gc *Client) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	var peers []*p2p.PeerInfo
+	err := gc.c.CallContext(ctx, &peers, "admin_peers")
+	return peers, err
+}
+
+// NodeInfo returns information on the node itself.
+func (gc *Client) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
+	var info *p2p.NodeInfo
+	err := gc.c.CallContext(ctx, &info, "admin_nodeInfo")
+	return info, err
+}
+
+// AddPeer asks the node to connect to the given enode URL.
+func (gc *Client) AddPeer(ctx context.Context, url string) error {
+	return gc.c.CallContext(ctx, nil, "admin_addPeer", url)
+}
+
+// RemovePeer asks the node to disconnect from the given enode URL.
+func (gc *Client) RemovePeer(ctx context.Context, url string) error {
+	return gc.c.CallContext(ctx, nil, "admin_removePeer", url)
+}
+
+// SubscribePeerEvents subscribes to peer connection and message events.
+func (gc *Client) SubscribePeerEvents(ctx context.Context, ch chan<- *p2p.PeerEvent) (epvchain.Subscription, error) {
+	return gc.c.Subscribe(ctx, "admin", ch, "peerEvents")
+}
+
+// TraceTransaction returns the structured logs of a transaction as created by
+// the default tracer. Any tracer set in config is ignored.
+func (gc *Client) TraceTransaction(ctx context.Context, hash common.Hash, config *epv.TraceConfig) (*ExecutionResult, error) {
+	if config != nil && config.Tracer != nil {
+		cpy := *config
+		cpy.Tracer = nil
+		config = &cpy
+	}
+	var result *ExecutionResult
+	err := gc.c.CallContext(ctx, &result, "debug_traceTransaction", hash, config)
+	return result, err
+}
+
+// TraceTransactionWithTracer traces a transaction with the tracer named in
+// config, decoding its output into result.
+func (gc *Client) TraceTransactionWithTracer(ctx context.Context, hash common.Hash, config *epv.TraceConfig, result interface{}) error {
+	return gc.c.CallContext(ctx, result, "debug_traceTransaction", hash, config)
+}
+
+// CallTrace returns the call tree of a transaction as created by the native
+// call tracer.
+func (gc *Client) CallTrace(ctx context.Context, hash common.Hash) (*tracers.CallFrame, error) {
+	tracer := "callTracer"
+
+	var frame *tracers.CallFrame
+	err := gc.TraceTransactionWithTracer(ctx, hash, &epv.TraceConfig{Tracer: &tracer}, &frame)
+	return frame, err
+}
+
+// PrestateTrace returns the accounts a transaction touched as they were
+// before it ran, as created by the native prestate tracer.
+func (gc *Client) PrestateTrace(ctx context.Context, hash common.Hash) (map[common.Address]*tracers.PrestateAccount, error) {
+	tracer := "prestateTracer"
+
+	var prestate map[common.Address]*tracers.PrestateAccount
+	err := gc.TraceTransactionWithTracer(ctx, hash, &epv.TraceConfig{Tracer: &tracer}, &prestate)
+	return prestate, err
+}
+
+// TraceBlockByNumber traces all transactions of the given block, or of the
+// latest block if number is nil.
+func (gc *Client) TraceBlockByNumber(ctx context.Context, number *big.Int, config *epv.TraceConfig) ([]*TxTraceResult, error) {
+	var results []*TxTraceResult
+	err := gc.c.CallContext(ctx, &results, "debug_traceBlockByNumber", toBlockNumArg(number), config)
+	return results, err
+}
+
+// TraceBlockByHash traces all transactions of the block with the given hash.
+func (gc *Client) TraceBlockByHash(ctx context.Context, hash common.Hash, config *epv.TraceConfig) ([]*TxTraceResult, error) {
+	var results []*TxTraceResult
+	err := gc.c.CallContext(ctx, &results, "debug_traceBlockByHash", hash, config)
+	return results, err
+}
+
+// TraceChain subscribes to the traces of the blocks after start up to and
+// including end, nil meaning the latest block for either.
+func (gc *Client) TraceChain(ctx context.Context, start, end *big.Int, config *epv.TraceConfig, ch chan<- *BlockTraceResult) (epvchain.Subscription, error) {
+	return gc.c.Subscribe(ctx, "debug", ch, "traceChain", toBlockNumArg(start), toBlockNumArg(end), config)
+}
+
+// TxPoolContent returns the pending and queued transactions of the pool,
+// grouped by sender and nonce.
+func (gc *Client) TxPoolContent(ctx context.Context) (map[string]map[string]map[string]*epvapi.RPCTransaction, error) {
+	var content map[string]map[string]map[string]*epvapi.RPCTransaction
+	err := gc.c.CallContext(ctx, &content, "txpool_content")
+	return content, err
+}
+
+// TxPoolInspect returns a textual summary of the pending and queued
+// transactions of the pool, grouped by sender and nonce.
+func (gc *Client) TxPoolInspect(ctx context.Context) (map[string]map[string]map[string]string, error) {
+	var content map[string]map[string]map[string]string
+	err := gc.c.CallContext(ctx, &content, "txpool_inspect")
+	return content, err
+}
+
+// TxPoolStatus returns the number of pending and queued transactions in the pool.
+func (gc *Client) TxPoolStatus(ctx context.Context) (pending, queued uint, err error) {
+	var status map[string]hexutil.Uint
+	if err := gc.c.CallContext(ctx, &status, "txpool_status"); err != nil {
+		return 0, 0, err
+	}
+	return uint(status["pending"]), uint(status["queued"]), nil
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
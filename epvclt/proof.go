@@ -0,0 +1,165 @@
+package epvclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/code"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/process"
+	"github.com/epvchain/go-epvchain/fast"
+)
+
+var (
+	errAccountMismatch = errors.New("account does not match proof")
+	errStorageMismatch = errors.New("storage slot does not match proof")
+	errProofMismatch   = errors.New("proof does not match the request")
+)
+
+// AccountResult is the EIP-1186 proof of an account and some of its storage
+// slots, as returned by GetProof.
+type AccountResult struct {
+	Address      common.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the proof of a single storage slot.
+type StorageResult struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+type accountResultMarshaling struct {
+	Address      common.Address            `json:"address"`
+	AccountProof []hexutil.Bytes           `json:"accountProof"`
+	Balance      *hexutil.Big              `json:"balance"`
+	CodeHash     common.Hash               `json:"codeHash"`
+	Nonce        hexutil.Uint64            `json:"nonce"`
+	StorageHash  common.Hash               `json:"storageHash"`
+	StorageProof []storageResultMarshaling `json:"storageProof"`
+}
+
+type storageResultMarshaling struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the Merkle proofs of the account and the given storage
+// slots at the given block, or the latest block if blockNumber is nil. Results
+// for another account or other slots are rejected. Use VerifyProof to check
+// the result against the state root of that block.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = key.Hex()
+	}
+	var res accountResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "epv_getProof", account, hexKeys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if res.Address != account {
+		return nil, fmt.Errorf("%v: account %x, requested %x", errProofMismatch, res.Address, account)
+	}
+	if len(res.StorageProof) != len(keys) {
+		return nil, fmt.Errorf("%v: %d storage proofs, requested %d", errProofMismatch, len(res.StorageProof), len(keys))
+	}
+	storage := make([]StorageResult, len(res.StorageProof))
+	for i, st := range res.StorageProof {
+		if key := common.HexToHash(st.Key); key != keys[i] {
+			return nil, fmt.Errorf("%v: storage slot %x, requested %x", errProofMismatch, key, keys[i])
+		}
+		storage[i] = StorageResult{
+			Key:   keys[i],
+			Value: (*big.Int)(st.Value),
+			Proof: fromHexSlice(st.Proof),
+		}
+	}
+	return &AccountResult{
+		Address:      res.Address,
+		AccountProof: fromHexSlice(res.AccountProof),
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: storage,
+	}, nil
+}
+
+func fromHexSlice(s []hexutil.Bytes) [][]byte {
+	r := make([][]byte, len(s))
+	for i := range s {
+		r[i] = s[i]
+	}
+	return r
+}
+
+// VerifyProof checks that the account and storage values in res are proven
+// by its Merkle proofs against the given state root, usually the Root of the
+// header the proof was requested for.
+func VerifyProof(root common.Hash, res *AccountResult) error {
+	enc, err := verifyNodes(root, crypto.Keccak256(res.Address.Bytes()), res.AccountProof)
+	if err != nil {
+		return fmt.Errorf("account %x: %v", res.Address, err)
+	}
+	want := state.Account{
+		Balance:  new(big.Int),
+		Root:     types.EmptyRootHash,
+		CodeHash: crypto.Keccak256(nil),
+	}
+	if enc != nil {
+		if err := rlp.DecodeBytes(enc, &want); err != nil {
+			return fmt.Errorf("account %x: %v", res.Address, err)
+		}
+	}
+	if res.Nonce != want.Nonce || res.Balance == nil || res.Balance.Cmp(want.Balance) != 0 ||
+		res.StorageHash != want.Root || !bytes.Equal(res.CodeHash[:], want.CodeHash) {
+		return fmt.Errorf("%v: %x", errAccountMismatch, res.Address)
+	}
+
+	for _, st := range res.StorageProof {
+		enc, err := verifyNodes(res.StorageHash, crypto.Keccak256(st.Key.Bytes()), st.Proof)
+		if err != nil {
+			return fmt.Errorf("storage slot %x: %v", st.Key, err)
+		}
+		value := new(big.Int)
+		if enc != nil {
+			_, content, _, err := rlp.Split(enc)
+			if err != nil {
+				return fmt.Errorf("storage slot %x: %v", st.Key, err)
+			}
+			value.SetBytes(content)
+		}
+		if st.Value == nil || st.Value.Cmp(value) != 0 {
+			return fmt.Errorf("%v: %x", errStorageMismatch, st.Key)
+		}
+	}
+	return nil
+}
+
+// verifyNodes returns the value stored under key in the trie with the given
+// root, or nil if the proof shows the key is absent.
+func verifyNodes(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db, _ := epvdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	if len(proof) == 0 && root == types.EmptyRootHash {
+		return nil, nil
+	}
+	value, err, _ := trie.VerifyProof(root, key, db)
+	return value, err
+}
@@ -0,0 +1,456 @@
+package epvclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/epvchain/go-epvchain"
+	"github.com/epvchain/go-epvchain/act/abi/bind"
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/public/hexutil"
+	"github.com/epvchain/go-epvchain/kernel/types"
+	"github.com/epvchain/go-epvchain/book"
+)
+
+var (
+	errNotCanonical   = errors.New("transaction not in canonical chain")
+	errGasPriceCapped = errors.New("replacement gas price exceeds policy maximum")
+)
+
+// updateTimeout bounds the node requests of a single replacement round.
+const updateTimeout = 30 * time.Second
+
+// TxPolicy configures how a TxManager treats transactions that stay unmined.
+type TxPolicy struct {
+	BumpInterval  time.Duration // Time a transaction may stay unmined before it is replaced
+	PriceBump     uint64        // Percentage a replacement raises the gas price by (at least the pool's 10%)
+	MaxGasPrice   *big.Int      // Gas price or fee cap replacements never exceed (nil = no limit)
+	Confirmations uint64        // Blocks a transaction is tracked for after being mined
+	PollInterval  time.Duration // Interval between checks of the chain and the receipts
+}
+
+// DefaultTxPolicy contains the default settings for replacing transactions.
+var DefaultTxPolicy = TxPolicy{
+	BumpInterval:  time.Minute,
+	PriceBump:     10,
+	Confirmations: 12,
+	PollInterval:  time.Second,
+}
+
+// sanitize checks the provided policy and changes anything that's unreasonable
+// or unworkable.
+func (policy *TxPolicy) sanitize() TxPolicy {
+	conf := *policy
+	if conf.BumpInterval < time.Second {
+		log.Warn("Sanitizing invalid transaction bump interval", "provided", conf.BumpInterval, "updated", DefaultTxPolicy.BumpInterval)
+		conf.BumpInterval = DefaultTxPolicy.BumpInterval
+	}
+	if conf.PriceBump < DefaultTxPolicy.PriceBump {
+		log.Warn("Sanitizing invalid transaction price bump", "provided", conf.PriceBump, "updated", DefaultTxPolicy.PriceBump)
+		conf.PriceBump = DefaultTxPolicy.PriceBump
+	}
+	if conf.PollInterval <= 0 {
+		log.Warn("Sanitizing invalid transaction poll interval", "provided", conf.PollInterval, "updated", DefaultTxPolicy.PollInterval)
+		conf.PollInterval = DefaultTxPolicy.PollInterval
+	}
+	return conf
+}
+
+// TxManager sends the transactions of a single account, allocating their
+// nonces locally and replacing the ones that stay unmined with a higher gas
+// price until they are included.
+type TxManager struct {
+	client  *Client
+	from    common.Address
+	signer  bind.SignerFn
+	chainID *big.Int // Chain to sign dynamic fee transactions for (nil = legacy transactions only)
+	policy  TxPolicy
+
+	mu       sync.Mutex
+	nonce    uint64                // Next nonce to allocate
+	synced   bool                  // Whether nonce is in line with the node's pending nonce
+	txs      map[uint64]*managedTx // Transactions not yet confirmed, by nonce
+	reserved map[uint64]struct{}   // Nonces allocated to transactions still being sent
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// managedTx is a transaction along with the replacements sent for it.
+type managedTx struct {
+	attempts []*types.Transaction // Signed versions of the transaction, the latest last
+	sent     time.Time            // Time the latest version was broadcast
+}
+
+// NewTxManager creates a transaction manager sending transactions of from
+// through client, signed by signer, and starts replacing stuck ones.
+func NewTxManager(client *Client, from common.Address, signer bind.SignerFn, chainID *big.Int, policy TxPolicy) *TxManager {
+	m := &TxManager{
+		client:   client,
+		from:     from,
+		signer:   signer,
+		chainID:  chainID,
+		policy:   policy.sanitize(),
+		txs:      make(map[uint64]*managedTx),
+		reserved: make(map[uint64]struct{}),
+		quit:     make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.loop()
+	return m
+}
+
+// Stop terminates the replacement of stuck transactions.
+func (m *TxManager) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// Send signs and broadcasts a transaction from the managed account with the
+// next local nonce. Missing gas and fee fields of msg are filled in like for
+// contract bindings. The nonce is re-synced with the node after a failure.
+func (m *TxManager) Send(ctx context.Context, msg epvchain.CallMsg) (*types.Transaction, error) {
+	nonce, err := m.reserve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.newTx(ctx, nonce, msg)
+	if err == nil {
+		err = m.client.SendTransaction(ctx, tx)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.reserved, nonce)
+	if err != nil {
+		m.synced = false
+		return nil, err
+	}
+	m.txs[nonce] = &managedTx{attempts: []*types.Transaction{tx}, sent: time.Now()}
+	return tx, nil
+}
+
+// reserve allocates the next nonce, re-syncing with the node first if needed.
+// Nonces still tracked or being sent are skipped, as the node's pending nonce
+// may lag behind them.
+func (m *TxManager) reserve(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	synced := m.synced
+	m.mu.Unlock()
+
+	var pending uint64
+	if !synced {
+		nonce, err := m.client.PendingNonceAt(ctx, m.from)
+		if err != nil {
+			return 0, err
+		}
+		pending = nonce
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !synced && !m.synced {
+		m.nonce, m.synced = pending, true
+	}
+	for {
+		_, tracked := m.txs[m.nonce]
+		_, reserved := m.reserved[m.nonce]
+		if !tracked && !reserved {
+			break
+		}
+		m.nonce++
+	}
+	nonce := m.nonce
+	m.reserved[nonce] = struct{}{}
+	m.nonce++
+	return nonce, nil
+}
+
+// newTx fills in the missing gas fields of msg and signs it with nonce.
+func (m *TxManager) newTx(ctx context.Context, nonce uint64, msg epvchain.CallMsg) (*types.Transaction, error) {
+	msg.From = m.from
+
+	var head *types.Header
+	if msg.GasPrice == nil && m.chainID != nil {
+		var err error
+		if head, err = m.client.HeaderByNumber(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
+	if head != nil && head.BaseFee != nil {
+		if msg.GasTipCap == nil {
+			price, err := m.client.SuggestGasPrice(ctx)
+			if err != nil {
+				return nil, err
+			}
+			msg.GasTipCap = new(big.Int).Sub(price, head.BaseFee)
+			if msg.GasTipCap.Sign() < 0 {
+				msg.GasTipCap.SetInt64(0)
+			}
+		}
+		if msg.GasFeeCap == nil {
+			msg.GasFeeCap = new(big.Int).Add(msg.GasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		}
+	} else if msg.GasPrice == nil {
+		price, err := m.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msg.GasPrice, msg.GasFeeCap, msg.GasTipCap = price, nil, nil
+	}
+	if msg.Gas == 0 {
+		gas, err := m.client.EstimateGas(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		msg.Gas = gas
+	}
+	return m.sign(nonce, msg)
+}
+
+// sign creates the transaction described by msg with the given nonce and
+// signs it as the managed account.
+func (m *TxManager) sign(nonce uint64, msg epvchain.CallMsg) (*types.Transaction, error) {
+	var tx *types.Transaction
+	switch {
+	case msg.GasFeeCap != nil && m.chainID != nil:
+		tx = types.NewDynamicFeeTransaction(m.chainID, nonce, msg.To, msg.Value, msg.Gas, msg.GasTipCap, msg.GasFeeCap, msg.Data, msg.AccessList)
+	case msg.AccessList != nil && m.chainID != nil:
+		tx = types.NewAccessListTransaction(m.chainID, nonce, msg.To, msg.Value, msg.Gas, msg.GasPrice, msg.Data, msg.AccessList)
+	case msg.To == nil:
+		tx = types.NewContractCreation(nonce, msg.Value, msg.Gas, msg.GasPrice, msg.Data)
+	default:
+		tx = types.NewTransaction(nonce, *msg.To, msg.Value, msg.Gas, msg.GasPrice, msg.Data)
+	}
+	return m.signer(m.from, tx)
+}
+
+// bump creates a replacement of tx paying a higher gas price.
+func (m *TxManager) bump(tx *types.Transaction) (*types.Transaction, error) {
+	msg := epvchain.CallMsg{
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	var price *big.Int
+	if tx.Type() == types.DynamicFeeTxType {
+		msg.GasFeeCap, msg.GasTipCap = m.bumpPrice(tx.GasFeeCap()), m.bumpPrice(tx.GasTipCap())
+		price = msg.GasFeeCap
+	} else {
+		msg.GasPrice = m.bumpPrice(tx.GasPrice())
+		price = msg.GasPrice
+	}
+	if m.policy.MaxGasPrice != nil && price.Cmp(m.policy.MaxGasPrice) > 0 {
+		return nil, errGasPriceCapped
+	}
+	return m.sign(tx.Nonce(), msg)
+}
+
+// bumpPrice raises price by the policy's price bump, by at least one wei.
+func (m *TxManager) bumpPrice(price *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+m.policy.PriceBump))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(price) <= 0 {
+		bumped.Add(price, common.Big1)
+	}
+	return bumped
+}
+
+// loop periodically replaces the transactions that stay unmined.
+func (m *TxManager) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.policy.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+			m.update(ctx)
+			cancel()
+
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// update forgets the transactions mined deep enough, and replaces or
+// rebroadcasts the ones that were not mined within the bump interval. The
+// account nonce is re-checked every round, so transactions dropped by a reorg
+// are picked up again.
+func (m *TxManager) update(ctx context.Context) {
+	m.mu.Lock()
+	tracked := len(m.txs)
+	m.mu.Unlock()
+
+	if tracked == 0 {
+		return
+	}
+	// Query the node without holding the lock, so Send is not blocked on it
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Debug("Failed to retrieve head header", "err", err)
+		return
+	}
+	var confirmed uint64
+	if number := head.Number.Uint64(); number >= m.policy.Confirmations {
+		if confirmed, err = m.client.NonceAt(ctx, m.from, new(big.Int).SetUint64(number-m.policy.Confirmations)); err != nil {
+			log.Debug("Failed to retrieve confirmed nonce", "err", err)
+			return
+		}
+	}
+	mined, err := m.client.NonceAt(ctx, m.from, head.Number)
+	if err != nil {
+		log.Debug("Failed to retrieve account nonce", "err", err)
+		return
+	}
+	// Forget the confirmed transactions and collect the stuck ones
+	m.mu.Lock()
+	stuck := make(map[uint64]*types.Transaction)
+	for nonce, mtx := range m.txs {
+		if nonce < confirmed {
+			delete(m.txs, nonce)
+			continue
+		}
+		if nonce < mined || time.Since(mtx.sent) < m.policy.BumpInterval {
+			continue
+		}
+		stuck[nonce] = mtx.attempts[len(mtx.attempts)-1]
+	}
+	m.mu.Unlock()
+
+	for nonce, tx := range stuck {
+		replacement, err := m.bump(tx)
+		if err == nil {
+			err = m.client.SendTransaction(ctx, replacement)
+		}
+		if err != nil {
+			log.Warn("Failed to replace stuck transaction", "nonce", nonce, "hash", tx.Hash(), "err", err)
+
+			// Rebroadcast the current version in case the node dropped it
+			m.client.SendTransaction(ctx, tx)
+			replacement = nil
+		} else {
+			log.Debug("Replaced stuck transaction", "nonce", nonce, "old", tx.Hash(), "new", replacement.Hash())
+		}
+		// Record the outcome unless the transaction changed in the meantime
+		m.mu.Lock()
+		if mtx, ok := m.txs[nonce]; ok && mtx.attempts[len(mtx.attempts)-1] == tx {
+			if replacement != nil {
+				mtx.attempts = append(mtx.attempts, replacement)
+			}
+			mtx.sent = time.Now()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// attempts returns the known versions of tx, the latest first, or known if
+// the transaction is no longer tracked.
+func (m *TxManager) attempts(tx *types.Transaction, known []*types.Transaction) []*types.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mtx, ok := m.txs[tx.Nonce()]
+	if !ok {
+		return known
+	}
+	for _, attempt := range mtx.attempts {
+		if attempt.Hash() == tx.Hash() {
+			attempts := make([]*types.Transaction, len(mtx.attempts))
+			for i, attempt := range mtx.attempts {
+				attempts[len(attempts)-1-i] = attempt
+			}
+			return attempts
+		}
+	}
+	return known
+}
+
+// WaitMined waits until tx or one of its replacements is included in the
+// canonical chain, returning the receipt of the included version.
+func (m *TxManager) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	return m.WaitConfirmed(ctx, tx, 1)
+}
+
+// WaitConfirmed waits until the block including tx or one of its replacements
+// is followed by n-1 blocks, returning the receipt of the included version.
+// Inclusion is re-checked against the canonical chain every round, so the
+// wait carries on if a reorg removes the transaction.
+func (m *TxManager) WaitConfirmed(ctx context.Context, tx *types.Transaction, n uint64) (*types.Receipt, error) {
+	ticker := time.NewTicker(m.policy.PollInterval)
+	defer ticker.Stop()
+
+	logger := log.New("nonce", tx.Nonce(), "hash", tx.Hash())
+	attempts := []*types.Transaction{tx}
+	for {
+		attempts = m.attempts(tx, attempts)
+		for _, attempt := range attempts {
+			receipt, number, err := m.client.canonicalReceipt(ctx, attempt.Hash())
+			if err != nil {
+				continue
+			}
+			head, err := m.client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				logger.Trace("Head retrieval failed", "err", err)
+				break
+			}
+			if head.Number.Uint64()+1 >= number+n {
+				return receipt, nil
+			}
+			logger.Trace("Transaction not yet confirmed", "number", number, "head", head.Number)
+			break
+		}
+		// Wait for the next round.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+type rpcReceipt struct {
+	receipt *types.Receipt
+	receiptExtraInfo
+}
+
+type receiptExtraInfo struct {
+	BlockHash   common.Hash
+	BlockNumber hexutil.Uint64
+}
+
+func (r *rpcReceipt) UnmarshalJSON(msg []byte) error {
+	if err := json.Unmarshal(msg, &r.receipt); err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, &r.receiptExtraInfo)
+}
+
+// canonicalReceipt returns the receipt of the transaction with the given hash
+// along with the number of its block, provided the block is canonical.
+func (ec *Client) canonicalReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, uint64, error) {
+	var r *rpcReceipt
+	if err := ec.c.CallContext(ctx, &r, "epv_getTransactionReceipt", hash); err != nil {
+		return nil, 0, err
+	} else if r == nil {
+		return nil, 0, epvchain.NotFound
+	}
+	var block *struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := ec.c.CallContext(ctx, &block, "epv_getBlockByNumber", r.BlockNumber, false); err != nil {
+		return nil, 0, err
+	} else if block == nil || block.Hash != r.BlockHash {
+		return nil, 0, errNotCanonical
+	}
+	return r.receipt, uint64(r.BlockNumber), nil
+}
@@ -136,6 +136,19 @@ func (db *Database) Reference(child common.Hash, parent common.Hash) {
 	db.reference(child, parent)
 }
 
+// ReferenceCached references root from the metaroot if it is still held in
+// memory, reporting whether it did. Roots already flushed to disk need none.
+func (db *Database) ReferenceCached(root common.Hash) bool {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, ok := db.nodes[root]; !ok {
+		return false
+	}
+	db.reference(root, common.Hash{})
+	return true
+}
+
 func (db *Database) reference(child common.Hash, parent common.Hash) {
 
 	node, ok := db.nodes[child]
@@ -16,6 +16,7 @@ import (
 	"github.com/epvchain/go-epvchain/epv/downloader"
 	"github.com/epvchain/go-epvchain/epv/gasprice"
 	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/local/epvapi"
 	"github.com/epvchain/go-epvchain/notice"
 	"github.com/epvchain/go-epvchain/simple"
 	"github.com/epvchain/go-epvchain/content"
@@ -44,6 +45,13 @@ func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.epv.blockchain.CurrentHeader(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		hash := core.GetFinalizedBlockHash(b.epv.chainDb)
+		if hash == (common.Hash{}) {
+			return nil, nil
+		}
+		return b.epv.blockchain.GetHeaderByHash(hash), nil
+	}
 
 	return b.epv.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
@@ -76,9 +84,10 @@ func (b *LesApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.epv.blockchain.GetTdByHash(blockHash)
 }
 
-func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, blockOverrides *epvapi.BlockOverrides) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	context := core.NewEVMContext(msg, header, b.epv.blockchain, nil)
+	blockOverrides.Apply(&context)
 	return vm.NewEVM(context, state, b.epv.chainConfig, vmCfg), state.Error, nil
 }
 
@@ -146,6 +155,10 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, rewardPercentiles)
+}
+
 func (b *LesApiBackend) ChainDb() epvdb.Database {
 	return b.epv.chainDb
 }
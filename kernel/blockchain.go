@@ -15,6 +15,7 @@ import (
 	"github.com/epvchain/go-epvchain/public/mclock"
 	"github.com/epvchain/go-epvchain/agreement"
 	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/kernel/state/snapshot"
 	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/kernel/vm"
 	"github.com/epvchain/go-epvchain/code"
@@ -50,6 +51,12 @@ type CacheConfig struct {
 	Disabled      bool
 	TrieNodeLimit int
 	TrieTimeLimit time.Duration
+	Snapshot      bool
+
+	// AncientThreshold is the number of recent blocks kept in the key-value
+	// store, older canonical blocks being moved into the freezer. Zero disables
+	// freezing.
+	AncientThreshold uint64
 }
 
 type BlockChain struct {
@@ -65,6 +72,7 @@ type BlockChain struct {
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
+	finalizedFeed event.Feed
 	logsFeed      event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
@@ -76,8 +84,10 @@ type BlockChain struct {
 	checkpoint       int
 	currentBlock     *types.Block
 	currentFastBlock *types.Block
+	finalizedBlock   *types.Block
 
 	stateCache   state.Database
+	snaps        *snapshot.Tree
 	bodyCache    *lru.Cache
 	bodyRLPCache *lru.Cache
 	blockCache   *lru.Cache
@@ -148,12 +158,17 @@ func NewBlockChain(db epvdb.Database, cacheConfig *CacheConfig, chainConfig *par
 
 			if headerByNumber != nil && headerByNumber.Hash() == header.Hash() {
 				log.Error("Found bad hash, rewinding chain", "number", header.Number, "hash", header.ParentHash)
-				bc.SetHead(header.Number.Uint64() - 1)
+				if err := bc.SetHead(header.Number.Uint64() - 1); err != nil {
+					return nil, err
+				}
 				log.Error("Chain rewind was successful, resuming normal operation")
 			}
 		}
 	}
 
+	if cacheConfig.Snapshot {
+		bc.snaps = snapshot.New(bc.db, bc.stateCache.TrieDB(), bc.CurrentBlock().Root())
+	}
 	go bc.update()
 	return bc, nil
 }
@@ -203,6 +218,13 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	if hash := GetFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		bc.finalizedBlock = bc.GetBlockByHash(hash)
+	}
+	if bc.finalizedBlock != nil {
+		bc.hc.SetFinalized(bc.finalizedBlock.NumberU64())
+	}
+
 	headerTd := bc.GetTd(currentHeader.Hash(), currentHeader.Number.Uint64())
 	blockTd := bc.GetTd(bc.currentBlock.Hash(), bc.currentBlock.NumberU64())
 	fastTd := bc.GetTd(bc.currentFastBlock.Hash(), bc.currentFastBlock.NumberU64())
@@ -223,7 +245,9 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.db, hash, num)
 	}
-	bc.hc.SetHead(head, delFn)
+	if err := bc.hc.SetHead(head, delFn); err != nil {
+		return err
+	}
 	currentHeader := bc.hc.CurrentHeader()
 
 	bc.bodyCache.Purge()
@@ -292,6 +316,38 @@ func (bc *BlockChain) CurrentBlock() *types.Block {
 	return bc.currentBlock
 }
 
+func (bc *BlockChain) FinalizedBlock() *types.Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.finalizedBlock
+}
+
+func (bc *BlockChain) SetFinalized(hash common.Hash, number uint64) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.finalizedBlock != nil && bc.finalizedBlock.NumberU64() >= number {
+		return nil
+	}
+	if GetCanonicalHash(bc.db, number) != hash {
+		return ErrNotFinalizable
+	}
+	block := bc.GetBlock(hash, number)
+	if block == nil {
+		return ErrNotFinalizable
+	}
+	if err := WriteFinalizedBlockHash(bc.db, hash); err != nil {
+		return err
+	}
+	bc.finalizedBlock = block
+	bc.hc.SetFinalized(number)
+	go bc.finalizedFeed.Send(ChainFinalizedEvent{Block: block})
+
+	log.Debug("Finalized block", "number", number, "hash", hash)
+	return nil
+}
+
 func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
@@ -328,7 +384,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 }
 
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshot(root, bc.stateCache, bc.snaps)
 }
 
 func (bc *BlockChain) Reset() error {
@@ -337,6 +393,12 @@ func (bc *BlockChain) Reset() error {
 
 func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 
+	bc.mu.Lock()
+	DeleteFinalizedBlockHash(bc.db)
+	bc.finalizedBlock = nil
+	bc.hc.SetFinalized(0)
+	bc.mu.Unlock()
+
 	if err := bc.SetHead(0); err != nil {
 		return err
 	}
@@ -457,8 +519,7 @@ func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 	if bc.blockCache.Contains(hash) {
 		return true
 	}
-	ok, _ := bc.db.Has(blockBodyKey(hash, number))
-	return ok
+	return hasBody(bc.db, hash, number)
 }
 
 func (bc *BlockChain) HasState(hash common.Hash) bool {
@@ -543,6 +604,15 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	if bc.snaps != nil {
+		root := bc.CurrentBlock().Root()
+		if number := bc.CurrentBlock().NumberU64(); !bc.cacheConfig.Disabled && number >= triesInMemory {
+			root = bc.GetBlockByNumber(number - triesInMemory + 1).Root()
+		}
+		if err := bc.snaps.Journal(root); err != nil {
+			log.Error("Failed to persist state snapshot", "err", err)
+		}
+	}
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 		if number := bc.CurrentBlock().NumberU64(); number >= triesInMemory {
@@ -980,7 +1050,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
-		state, err := state.New(parent.Root(), bc.stateCache)
+		state, err := state.NewWithSnapshot(parent.Root(), bc.stateCache, bc.snaps)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
@@ -1139,6 +1209,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 	}
 
+	if bc.finalizedBlock != nil && commonBlock.NumberU64() < bc.finalizedBlock.NumberU64() {
+		log.Warn("Refusing reorg below finalized block", "number", commonBlock.Number(), "hash", commonBlock.Hash(), "finalized", bc.finalizedBlock.Number())
+		return ErrFinalizedReorg
+	}
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
 		if len(oldChain) > 63 {
@@ -1202,16 +1276,77 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 func (bc *BlockChain) update() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+
+	freezeTimer := time.NewTicker(time.Minute)
+	defer freezeTimer.Stop()
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-freezeTimer.C:
+			bc.freeze()
 		case <-bc.quit:
 			return
 		}
 	}
 }
 
+// freeze moves canonical blocks older than the configured threshold into the
+// ancient store, if the chain database has one attached.
+func (bc *BlockChain) freeze() {
+	threshold := bc.cacheConfig.AncientThreshold
+	if threshold == 0 {
+		return
+	}
+	db, ok := bc.db.(*epvdb.LDBDatabase)
+	if !ok || db.Freezer() == nil {
+		return
+	}
+	head := bc.CurrentBlock().NumberU64()
+	if fast := bc.CurrentFastBlock().NumberU64(); fast < head {
+		head = fast
+	}
+	// Blocks are copied without holding the chain lock, so only freeze the
+	// ones no reorg can replace anymore: the finalized ones if the chain has
+	// finality, the ones deeper than the immutability threshold otherwise.
+	var limit uint64
+	if finalized := bc.FinalizedBlock(); finalized != nil {
+		limit = finalized.NumberU64() + 1
+	} else if head > immutabilityThreshold {
+		limit = head - immutabilityThreshold
+	}
+	if head <= threshold {
+		return
+	}
+	if head-threshold < limit {
+		limit = head - threshold
+	}
+	start := time.Now()
+
+	first, frozen, err := copyAncients(db, limit)
+	if err != nil {
+		log.Error("Failed to freeze ancient blocks", "err", err)
+	}
+	if frozen == 0 {
+		return
+	}
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	if !checkFrozen(db, first, first+frozen) {
+		log.Error("Frozen blocks no longer canonical, keeping them in the database", "from", first, "to", first+frozen-1)
+		if err := db.Freezer().TruncateAncients(first); err != nil {
+			log.Error("Failed to discard non-canonical frozen blocks", "err", err)
+		}
+		return
+	}
+	if err := pruneFrozen(db, first, first+frozen); err != nil {
+		log.Error("Failed to prune frozen blocks", "err", err)
+		return
+	}
+	log.Info("Froze ancient blocks", "from", first, "to", first+frozen-1, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 type BadBlockArgs struct {
 	Hash   common.Hash   `json:"hash"`
 	Header *types.Header `json:"header"`
@@ -1337,6 +1472,10 @@ func (bc *BlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Su
 	return bc.scope.Track(bc.chainHeadFeed.Subscribe(ch))
 }
 
+func (bc *BlockChain) SubscribeChainFinalizedEvent(ch chan<- ChainFinalizedEvent) event.Subscription {
+	return bc.scope.Track(bc.finalizedFeed.Subscribe(ch))
+}
+
 func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Subscription {
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
@@ -27,6 +27,7 @@ type BlockGen struct {
 	chainReader consensus.ChainReader
 	header      *types.Header
 	statedb     *state.StateDB
+	author      *common.Address
 
 	gasPool  *GasPool
 	txs      []*types.Transaction
@@ -52,12 +53,28 @@ func (b *BlockGen) SetExtra(data []byte) {
 	b.header.Extra = data
 }
 
+// SetAuthor sets the account credited with the fees of the transactions added
+// afterwards, for engines deriving the block author from the seal rather than
+// from the coinbase.
+func (b *BlockGen) SetAuthor(addr common.Address) {
+	b.author = &addr
+}
+
 func (b *BlockGen) AddTx(tx *types.Transaction) {
+	b.AddTxWithChain(nil, tx)
+}
+
+// AddTxWithChain adds tx like AddTx, resolving the BLOCKHASH opcode through bc.
+func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
 	if b.gasPool == nil {
 		b.SetCoinbase(common.Address{})
 	}
+	author := &b.header.Coinbase
+	if b.author != nil {
+		author = b.author
+	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	receipt, _, err := ApplyTransaction(b.config, nil, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
+	receipt, _, err := ApplyTransaction(b.config, bc, author, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
 	if err != nil {
 		panic(err)
 	}
@@ -165,20 +182,19 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		time = new(big.Int).Add(parent.TimeMS(), big.NewInt(10*1000))
 	}
 
-	return &types.Header{
+	header := &types.Header{
 		Root:       state.IntermediateRoot(chain.Config().IsEIP158(parent.Number())),
 		ParentHash: parent.Hash(),
 		Coinbase:   parent.Coinbase(),
-		Difficulty: engine.CalcDifficulty(chain, time.Uint64(), &types.Header{
-			Number:     parent.Number(),
-			TimeMS:     new(big.Int).Sub(time, big.NewInt(10*1000)),
-			Difficulty: parent.Difficulty(),
-			UncleHash:  parent.UncleHash(),
-		}),
-		GasLimit: CalcGasLimit(parent),
-		Number:   new(big.Int).Add(parent.Number(), common.Big1),
-		TimeMS:   time,
+		Difficulty: engine.CalcDifficulty(chain, time.Uint64(), parent.Header()),
+		GasLimit:   CalcGasLimit(parent),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		TimeMS:     time,
+	}
+	if chain.Config().IsBaseFee(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(chain.Config(), parent.Header())
 	}
+	return header
 }
 
 func newCanonical(engine consensus.Engine, n int, full bool) (epvdb.Database, *BlockChain, error) {
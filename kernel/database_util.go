@@ -26,11 +26,35 @@ type DatabaseDeleter interface {
 	Delete(key []byte) error
 }
 
+// AncientReader is implemented by databases backed by a freezer holding the
+// immutable part of the canonical chain.
+type AncientReader interface {
+	Ancient(kind string, number uint64) ([]byte, error)
+	Ancients() uint64
+}
+
+// readAncient returns the frozen item of the given kind for the canonical block
+// with the given hash and number, or nil if the block is not in the freezer.
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	ancients, ok := db.(AncientReader)
+	if !ok || number >= ancients.Ancients() {
+		return nil
+	}
+	frozen, err := ancients.Ancient(epvdb.FreezerHashTable, number)
+	if err != nil || common.BytesToHash(frozen) != hash {
+		return nil
+	}
+	data, _ := ancients.Ancient(kind, number)
+	return data
+}
+
 var (
 	headHeaderKey = []byte("LastHeader")
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
 
+	finalizedBlockKey = []byte("LastFinalized")
+
 	headerPrefix        = []byte("h") 
 	tdSuffix            = []byte("t") 
 	numSuffix           = []byte("n") 
@@ -68,6 +92,11 @@ func encodeBlockNumber(number uint64) []byte {
 
 func GetCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+	if len(data) == 0 {
+		if ancients, ok := db.(AncientReader); ok && number < ancients.Ancients() {
+			data, _ = ancients.Ancient(epvdb.FreezerHashTable, number)
+		}
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
@@ -108,8 +137,19 @@ func GetHeadFastBlockHash(db DatabaseReader) common.Hash {
 	return common.BytesToHash(data)
 }
 
+func GetFinalizedBlockHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(finalizedBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(hash, number))
+	if len(data) == 0 {
+		data = readAncient(db, epvdb.FreezerHeaderTable, hash, number)
+	}
 	return data
 }
 
@@ -128,9 +168,28 @@ func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header
 
 func GetBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(blockBodyKey(hash, number))
+	if len(data) == 0 {
+		data = readAncient(db, epvdb.FreezerBodiesTable, hash, number)
+	}
 	return data
 }
 
+// hasHeader reports whether the header is present either in the key-value
+// store or in the freezer.
+func hasHeader(db epvdb.Database, hash common.Hash, number uint64) bool {
+	if ok, _ := db.Has(headerKey(hash, number)); ok {
+		return true
+	}
+	return len(readAncient(db, epvdb.FreezerHashTable, hash, number)) > 0
+}
+
+func hasBody(db epvdb.Database, hash common.Hash, number uint64) bool {
+	if ok, _ := db.Has(blockBodyKey(hash, number)); ok {
+		return true
+	}
+	return len(readAncient(db, epvdb.FreezerHashTable, hash, number)) > 0
+}
+
 func headerKey(hash common.Hash, number uint64) []byte {
 	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
@@ -154,6 +213,9 @@ func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
 
 func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, epvdb.FreezerDifficultyTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -181,6 +243,9 @@ func GetBlock(db DatabaseReader, hash common.Hash, number uint64) *types.Block {
 
 func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		data = readAncient(db, epvdb.FreezerReceiptTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -307,6 +372,13 @@ func WriteHeadFastBlockHash(db epvdb.Putter, hash common.Hash) error {
 	return nil
 }
 
+func WriteFinalizedBlockHash(db epvdb.Putter, hash common.Hash) error {
+	if err := db.Put(finalizedBlockKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last finalized block's hash", "err", err)
+	}
+	return nil
+}
+
 func WriteHeader(db epvdb.Putter, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
 	if err != nil {
@@ -418,6 +490,10 @@ func DeleteCanonicalHash(db DatabaseDeleter, number uint64) {
 	db.Delete(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
 }
 
+func DeleteFinalizedBlockHash(db DatabaseDeleter) {
+	db.Delete(finalizedBlockKey)
+}
+
 func DeleteHeader(db DatabaseDeleter, hash common.Hash, number uint64) {
 	db.Delete(append(blockHashPrefix, hash.Bytes()...))
 	db.Delete(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
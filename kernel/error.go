@@ -12,4 +12,12 @@ var (
 	ErrBlacklistedHash = errors.New("blacklisted hash")
 
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
+
+	ErrFinalizedReorg = errors.New("reorg below finalized block")
+
+	ErrNotFinalizable = errors.New("block not finalizable")
 )
@@ -31,3 +31,5 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+type ChainFinalizedEvent struct{ Block *types.Block }
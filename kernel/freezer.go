@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/process"
+)
+
+// freezerBatchLimit caps the number of blocks moved into the freezer in a
+// single round so the key-value store is not locked out for too long.
+const freezerBatchLimit = 30000
+
+// immutabilityThreshold is the depth below which blocks are frozen on chains
+// without finality, beyond any reorg the chain is expected to see.
+const immutabilityThreshold = 90000
+
+var (
+	errNoFreezer      = errors.New("database has no ancient store attached")
+	errMissingAncient = errors.New("canonical block data missing from database")
+)
+
+// FreezeAncients moves every canonical block below limit from the key-value
+// store into the attached freezer, then deletes the moved data along with any
+// side chain data at the same heights. The hash to number mappings and the
+// transaction lookup entries are kept, as they are only indexed by hash.
+func FreezeAncients(db *epvdb.LDBDatabase, limit uint64) (uint64, error) {
+	start := time.Now()
+
+	first, frozen, err := copyAncients(db, limit)
+	if err != nil || frozen == 0 {
+		return frozen, err
+	}
+	if err := pruneFrozen(db, first, first+frozen); err != nil {
+		return frozen, err
+	}
+	log.Info("Froze ancient blocks", "from", first, "to", first+frozen-1, "elapsed", common.PrettyDuration(time.Since(start)))
+	return frozen, nil
+}
+
+// copyAncients appends the canonical blocks below limit to the freezer
+// without touching the key-value store, returning the first block copied and
+// the number of blocks copied.
+func copyAncients(db *epvdb.LDBDatabase, limit uint64) (uint64, uint64, error) {
+	freezer := db.Freezer()
+	if freezer == nil {
+		return 0, 0, errNoFreezer
+	}
+	var (
+		first  = freezer.Ancients()
+		start  = time.Now()
+		logged = time.Now()
+	)
+	if limit > first+freezerBatchLimit {
+		limit = first + freezerBatchLimit
+	}
+	for number := first; number < limit; number++ {
+		hash := GetCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			return first, number - first, errMissingAncient
+		}
+		header, _ := db.Get(headerKey(hash, number))
+		body, _ := db.Get(blockBodyKey(hash, number))
+		td, _ := db.Get(append(headerKey(hash, number), tdSuffix...))
+		if len(header) == 0 || len(body) == 0 || len(td) == 0 {
+			log.Error("Missing canonical block data", "number", number, "hash", hash)
+			return first, number - first, errMissingAncient
+		}
+		receipts, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+		if len(receipts) == 0 {
+			receipts = rlp.EmptyList
+		}
+		if err := freezer.AppendAncient(number, hash[:], header, body, receipts, td); err != nil {
+			return first, number - first, err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Freezing ancient blocks", "number", number, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if limit <= first {
+		return first, 0, nil
+	}
+	if err := freezer.Sync(); err != nil {
+		return first, 0, err
+	}
+	return first, limit - first, nil
+}
+
+// checkFrozen reports whether the blocks frozen in [first, limit) are still
+// the canonical ones in the key-value store.
+func checkFrozen(db *epvdb.LDBDatabase, first, limit uint64) bool {
+	for number := first; number < limit; number++ {
+		frozen, _ := db.Ancient(epvdb.FreezerHashTable, number)
+		if hash := GetCanonicalHash(db, number); !bytes.Equal(hash[:], frozen) {
+			return false
+		}
+	}
+	return true
+}
+
+func pruneFrozen(db *epvdb.LDBDatabase, first, limit uint64) error {
+	batch := db.NewBatch()
+	for number := first; number < limit; number++ {
+		canonical, _ := db.Ancient(epvdb.FreezerHashTable, number)
+
+		for _, prefix := range [][]byte{headerPrefix, bodyPrefix, blockReceiptsPrefix} {
+			key := append(append([]byte{}, prefix...), encodeBlockNumber(number)...)
+
+			it := db.NewIterator()
+			for ok := it.Seek(key); ok && bytes.HasPrefix(it.Key(), key); ok = it.Next() {
+				k := it.Key()
+				if bytes.Equal(prefix, headerPrefix) && len(k) == len(key)+common.HashLength && !bytes.Equal(k[len(key):], canonical) {
+					batch.Delete(append(append([]byte{}, blockHashPrefix...), k[len(key):]...))
+				}
+				batch.Delete(common.CopyBytes(k))
+			}
+			it.Release()
+			if err := it.Error(); err != nil {
+				return err
+			}
+		}
+		if batch.ValueSize() >= epvdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// OpenAncients attaches the freezer kept in the "ancient" folder of the chain
+// database. An existing freezer is always opened, as part of the chain would be
+// unreachable otherwise; a new one is only created if requested.
+func OpenAncients(db *epvdb.LDBDatabase, create bool, compress bool) error {
+	dir := filepath.Join(db.Path(), "ancient")
+	if _, err := os.Stat(dir); err != nil {
+		if !os.IsNotExist(err) || !create {
+			return nil
+		}
+	}
+	return db.OpenFreezer(dir, compress)
+}
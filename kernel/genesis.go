@@ -114,6 +114,11 @@ func SetupGenesisBlock(db epvdb.Database, genesis *Genesis) (*params.ChainConfig
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEPVhashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil && genesis.Config.DPos != nil {
+		if err := genesis.Config.DPos.Validate(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 
 	stored := GetCanonicalHash(db, 0)
 	if (stored == common.Hash{}) {
@@ -146,6 +151,11 @@ func SetupGenesisBlock(db epvdb.Database, genesis *Genesis) (*params.ChainConfig
 	}
 
 	if genesis == nil && stored != params.MainnetGenesisHash {
+		if storedcfg.DPos != nil {
+			if err := storedcfg.DPos.Validate(); err != nil {
+				return storedcfg, stored, err
+			}
+		}
 		return storedcfg, stored, nil
 	}
 
@@ -153,9 +163,14 @@ func SetupGenesisBlock(db epvdb.Database, genesis *Genesis) (*params.ChainConfig
 	if height == missingNumber {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
-	compatErr := storedcfg.CheckCompatible(newcfg, height)
-	if compatErr != nil && height != 0 && compatErr.RewindTo != 0 {
-		return newcfg, stored, compatErr
+	switch err := storedcfg.CheckCompatible(newcfg, height).(type) {
+	case nil:
+	case *params.ConfigCompatError:
+		if height != 0 && err.RewindTo != 0 {
+			return newcfg, stored, err
+		}
+	default:
+		return newcfg, stored, err
 	}
 	return newcfg, stored, WriteChainConfig(db, stored, newcfg)
 }
@@ -206,6 +221,9 @@ func (g *Genesis) ToBlock(db epvdb.Database) *types.Block {
 	if g.Difficulty == nil {
 		head.Difficulty = params.GenesisDifficulty
 	}
+	if g.Config != nil && g.Config.IsBaseFee(head.Number) {
+		head.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+	}
 	statedb.Commit(false)
 	statedb.Database().TrieDB().Commit(root, true)
 
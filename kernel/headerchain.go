@@ -8,6 +8,7 @@ import (
 	"math"
 	"math/big"
 	mrand "math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/epvchain/go-epvchain/public"
@@ -40,6 +41,8 @@ type HeaderChain struct {
 
 	procInterrupt func() bool
 
+	finalized uint64 // Number of the finalized header, headers at or below it are never rewound
+
 	rand   *mrand.Rand
 	engine consensus.Engine
 }
@@ -113,7 +116,12 @@ func (hc *HeaderChain) WriteHeader(header *types.Header) (status WriteStatus, er
 		log.Crit("Failed to write header content", "err", err)
 	}
 
-	if externTd.Cmp(localTd) > 0 || (externTd.Cmp(localTd) == 0 && mrand.Float64() < 0.5) {
+	reorg := externTd.Cmp(localTd) > 0 || (externTd.Cmp(localTd) == 0 && mrand.Float64() < 0.5)
+	if reorg && hc.forksBelowFinalized(header) {
+		log.Warn("Refusing header reorg below finalized block", "number", number, "hash", hash, "finalized", atomic.LoadUint64(&hc.finalized))
+		reorg = false
+	}
+	if reorg {
 
 		for i := number + 1; ; i++ {
 			hash := GetCanonicalHash(hc.chainDb, i)
@@ -155,6 +163,29 @@ func (hc *HeaderChain) WriteHeader(header *types.Header) (status WriteStatus, er
 	return
 }
 
+// forksBelowFinalized reports whether making header canonical would replace
+// the canonical header at or below the finalized number.
+func (hc *HeaderChain) forksBelowFinalized(header *types.Header) bool {
+	finalized := atomic.LoadUint64(&hc.finalized)
+	if finalized == 0 {
+		return false
+	}
+	hash, number := header.Hash(), header.Number.Uint64()
+	for {
+		if GetCanonicalHash(hc.chainDb, number) == hash {
+			return false
+		}
+		if number <= finalized {
+			return true
+		}
+		parent := hc.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return true
+		}
+		header, hash, number = parent, header.ParentHash, number-1
+	}
+}
+
 type WhCallback func(*types.Header) error
 
 func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
@@ -299,8 +330,7 @@ func (hc *HeaderChain) HasHeader(hash common.Hash, number uint64) bool {
 	if hc.numberCache.Contains(hash) || hc.headerCache.Contains(hash) {
 		return true
 	}
-	ok, _ := hc.chainDb.Has(headerKey(hash, number))
-	return ok
+	return hasHeader(hc.chainDb, hash, number)
 }
 
 func (hc *HeaderChain) GetHeaderByNumber(number uint64) *types.Header {
@@ -325,7 +355,11 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
 
 type DeleteCallback func(common.Hash, uint64)
 
-func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) {
+func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) error {
+	if finalized := atomic.LoadUint64(&hc.finalized); head < finalized {
+		log.Warn("Refusing rewind below finalized block", "target", head, "finalized", finalized)
+		return ErrFinalizedReorg
+	}
 	height := uint64(0)
 	if hc.currentHeader != nil {
 		height = hc.currentHeader.Number.Uint64()
@@ -358,6 +392,13 @@ func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) {
 	if err := WriteHeadHeaderHash(hc.chainDb, hc.currentHeaderHash); err != nil {
 		log.Crit("Failed to reset head header hash", "err", err)
 	}
+	return nil
+}
+
+// SetFinalized sets the number of the finalized header, below which SetHead
+// and WriteHeader refuse to rewind the canonical chain.
+func (hc *HeaderChain) SetFinalized(number uint64) {
+	atomic.StoreUint64(&hc.finalized, number)
 }
 
 func (hc *HeaderChain) SetGenesis(head *types.Header) {
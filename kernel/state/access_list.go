@@ -0,0 +1,136 @@
+package state
+
+import (
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
+)
+
+// accessList tracks the addresses and storage slots already accessed by the
+// current transaction. Slots are only recorded for addresses in the list.
+type accessList struct {
+	addresses map[common.Address]int
+	slots     []map[common.Hash]struct{}
+}
+
+func newAccessList() *accessList {
+	return &accessList{addresses: make(map[common.Address]int)}
+}
+
+func (al *accessList) ContainsAddress(address common.Address) bool {
+	_, ok := al.addresses[address]
+	return ok
+}
+
+func (al *accessList) Contains(address common.Address, slot common.Hash) (addressPresent bool, slotPresent bool) {
+	idx, ok := al.addresses[address]
+	if !ok {
+		return false, false
+	}
+	if idx == -1 {
+		return true, false
+	}
+	_, slotPresent = al.slots[idx][slot]
+	return true, slotPresent
+}
+
+func (al *accessList) Copy() *accessList {
+	cpy := newAccessList()
+	for addr, idx := range al.addresses {
+		cpy.addresses[addr] = idx
+	}
+	cpy.slots = make([]map[common.Hash]struct{}, len(al.slots))
+	for i, slots := range al.slots {
+		cpy.slots[i] = make(map[common.Hash]struct{}, len(slots))
+		for slot := range slots {
+			cpy.slots[i][slot] = struct{}{}
+		}
+	}
+	return cpy
+}
+
+func (al *accessList) AddAddress(address common.Address) bool {
+	if _, present := al.addresses[address]; present {
+		return false
+	}
+	al.addresses[address] = -1
+	return true
+}
+
+// AddSlot adds the slot, and the address if needed, reporting which of the
+// two were not yet present.
+func (al *accessList) AddSlot(address common.Address, slot common.Hash) (addrChange bool, slotChange bool) {
+	idx, addrPresent := al.addresses[address]
+	if !addrPresent || idx == -1 {
+		al.addresses[address] = len(al.slots)
+		al.slots = append(al.slots, map[common.Hash]struct{}{slot: {}})
+		return !addrPresent, true
+	}
+	if _, ok := al.slots[idx][slot]; ok {
+		return false, false
+	}
+	al.slots[idx][slot] = struct{}{}
+	return false, true
+}
+
+// DeleteSlot and DeleteAddress undo the latest additions. They rely on the
+// journal reverting changes in reverse order.
+func (al *accessList) DeleteSlot(address common.Address, slot common.Hash) {
+	idx, ok := al.addresses[address]
+	if !ok {
+		panic("reverting slot change, address not present in list")
+	}
+	slots := al.slots[idx]
+	delete(slots, slot)
+	if len(slots) == 0 {
+		al.slots = al.slots[:idx]
+		al.addresses[address] = -1
+	}
+}
+
+func (al *accessList) DeleteAddress(address common.Address) {
+	delete(al.addresses, address)
+}
+
+// PrepareAccessList resets the access list for a new transaction, warming up
+// the sender, the recipient, the precompiles and the declared access list.
+func (self *StateDB) PrepareAccessList(sender common.Address, dst *common.Address, precompiles []common.Address, list types.AccessList) {
+	self.accessList = newAccessList()
+
+	self.AddAddressToAccessList(sender)
+	if dst != nil {
+		self.AddAddressToAccessList(*dst)
+	}
+	for _, addr := range precompiles {
+		self.AddAddressToAccessList(addr)
+	}
+	for _, tuple := range list {
+		self.AddAddressToAccessList(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			self.AddSlotToAccessList(tuple.Address, key)
+		}
+	}
+}
+
+func (self *StateDB) AddAddressToAccessList(addr common.Address) {
+	if self.accessList.AddAddress(addr) {
+		self.journal = append(self.journal, accessListAddAccountChange{&addr})
+	}
+}
+
+func (self *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	addrMod, slotMod := self.accessList.AddSlot(addr, slot)
+	if addrMod {
+		self.journal = append(self.journal, accessListAddAccountChange{&addr})
+	}
+	if slotMod {
+		self.journal = append(self.journal, accessListAddSlotChange{address: &addr, slot: &slot})
+	}
+}
+
+func (self *StateDB) AddressInAccessList(addr common.Address) bool {
+	return self.accessList.ContainsAddress(addr)
+}
+
+func (self *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool) {
+	return self.accessList.Contains(addr, slot)
+}
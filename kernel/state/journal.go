@@ -58,6 +58,14 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+
+	accessListAddAccountChange struct {
+		address *common.Address
+	}
+	accessListAddSlotChange struct {
+		address *common.Address
+		slot    *common.Hash
+	}
 )
 
 func (ch createObjectChange) undo(s *StateDB) {
@@ -121,3 +129,11 @@ func (ch addLogChange) undo(s *StateDB) {
 func (ch addPreimageChange) undo(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
+
+func (ch accessListAddAccountChange) undo(s *StateDB) {
+	s.accessList.DeleteAddress(*ch.address)
+}
+
+func (ch accessListAddSlotChange) undo(s *StateDB) {
+	s.accessList.DeleteSlot(*ch.address, *ch.slot)
+}
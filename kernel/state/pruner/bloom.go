@@ -0,0 +1,122 @@
+package pruner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/epvchain/go-epvchain/public"
+)
+
+const bloomHashes = 4
+
+var (
+	bloomMagic = []byte("epvprune")
+
+	errCorruptedBloom = errors.New("corrupted state bloom filter")
+)
+
+type stateBloom struct {
+	bits []byte
+}
+
+func newStateBloom(size uint64) *stateBloom {
+	if size == 0 {
+		size = 1
+	}
+	return &stateBloom{bits: make([]byte, size)}
+}
+
+func (b *stateBloom) positions(hash []byte) [bloomHashes]uint64 {
+	var (
+		pos  [bloomHashes]uint64
+		bits = uint64(len(b.bits)) * 8
+	)
+	for i := 0; i < bloomHashes; i++ {
+		pos[i] = binary.BigEndian.Uint64(hash[i*8:]) % bits
+	}
+	return pos
+}
+
+func (b *stateBloom) add(hash common.Hash) {
+	for _, pos := range b.positions(hash[:]) {
+		b.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+func (b *stateBloom) contains(key []byte) bool {
+	for _, pos := range b.positions(key) {
+		if b.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *stateBloom) commit(path string, root common.Hash) error {
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(bloomMagic); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := w.Write(root[:]); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := w.Write(b.bits); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadStateBloom(path string) (*stateBloom, common.Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	header := int64(len(bloomMagic) + common.HashLength)
+	if stat.Size() <= header {
+		return nil, common.Hash{}, errCorruptedBloom
+	}
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(bloomMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != string(bloomMagic) {
+		return nil, common.Hash{}, errCorruptedBloom
+	}
+	var root common.Hash
+	if _, err := io.ReadFull(r, root[:]); err != nil {
+		return nil, common.Hash{}, errCorruptedBloom
+	}
+	bloom := newStateBloom(uint64(stat.Size() - header))
+	if _, err := io.ReadFull(r, bloom.bits); err != nil {
+		return nil, common.Hash{}, errCorruptedBloom
+	}
+	return bloom, root, nil
+}
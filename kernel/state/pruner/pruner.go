@@ -0,0 +1,215 @@
+package pruner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/kernel/state"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	bloomFileName = "statebloom.bf"
+
+	// recentStateLimit is how far back from the head the pruner searches for a
+	// state root that has been fully committed to disk.
+	recentStateLimit = 1024
+)
+
+var (
+	errNoRecentState = errors.New("no committed state found near the chain head")
+	errMissingState  = errors.New("target state is not available")
+	errRootMismatch  = errors.New("interrupted pruning targets a different state root")
+	errNotLevelDB    = errors.New("interrupted state pruning requires a leveldb database")
+)
+
+type Pruner struct {
+	db        *epvdb.LDBDatabase
+	datadir   string
+	bloomSize uint64
+}
+
+func NewPruner(db *epvdb.LDBDatabase, datadir string, bloomSize uint64) *Pruner {
+	return &Pruner{db: db, datadir: datadir, bloomSize: bloomSize}
+}
+
+func (p *Pruner) bloomPath() string {
+	return filepath.Join(p.datadir, bloomFileName)
+}
+
+func (p *Pruner) Interrupted() bool {
+	_, err := os.Stat(p.bloomPath())
+	return err == nil
+}
+
+// RecentRoot returns the most recent state root near the chain head that is
+// committed to disk. It may lie below the head, in which case the chain is
+// rewound to it on the next start, as the state above it gets pruned.
+func (p *Pruner) RecentRoot() (common.Hash, uint64, error) {
+	head := core.GetHeadBlockHash(p.db)
+	if head == (common.Hash{}) {
+		return common.Hash{}, 0, errNoRecentState
+	}
+	number := core.GetBlockNumber(p.db, head)
+	for i := uint64(0); i <= recentStateLimit && i <= number; i++ {
+		hash := core.GetCanonicalHash(p.db, number-i)
+		header := core.GetHeader(p.db, hash, number-i)
+		if header == nil {
+			continue
+		}
+		if ok, _ := p.db.Has(header.Root[:]); ok {
+			return header.Root, header.Number.Uint64(), nil
+		}
+	}
+	return common.Hash{}, 0, errNoRecentState
+}
+
+// RecoverPruning finishes a state pruning interrupted during its sweep. A node
+// must not run on such a database: the state it writes is not marked in the
+// bloom filter, so resuming the pruning later would delete it.
+func RecoverPruning(datadir string, db epvdb.Database) error {
+	if datadir == "" {
+		return nil
+	}
+	p := &Pruner{datadir: datadir}
+	if !p.Interrupted() {
+		return nil
+	}
+	ldb, ok := db.(*epvdb.LDBDatabase)
+	if !ok {
+		return errNotLevelDB
+	}
+	p.db = ldb
+
+	log.Warn("Finishing interrupted state pruning", "bloom", p.bloomPath())
+	return p.Prune(common.Hash{})
+}
+
+func (p *Pruner) Prune(root common.Hash) error {
+	bloom, marked, err := loadStateBloom(p.bloomPath())
+	switch {
+	case err == nil:
+		if root != (common.Hash{}) && root != marked {
+			return fmt.Errorf("%v: have %x, requested %x", errRootMismatch, marked, root)
+		}
+		log.Info("Resuming interrupted state pruning", "root", marked)
+		root = marked
+
+	case os.IsNotExist(err):
+		if root == (common.Hash{}) {
+			return errMissingState
+		}
+		if bloom, err = p.mark(root); err != nil {
+			return err
+		}
+		if err := bloom.commit(p.bloomPath(), root); err != nil {
+			return err
+		}
+
+	default:
+		return err
+	}
+	if err := p.sweep(bloom); err != nil {
+		return err
+	}
+	start := time.Now()
+	log.Info("Compacting database")
+	if err := p.db.LDB().CompactRange(util.Range{}); err != nil {
+		return err
+	}
+	log.Info("Compacted database", "elapsed", common.PrettyDuration(time.Since(start)))
+
+	return os.Remove(p.bloomPath())
+}
+
+func (p *Pruner) mark(root common.Hash) (*stateBloom, error) {
+	var (
+		bloom  = newStateBloom(p.bloomSize)
+		roots  = []common.Hash{root}
+		start  = time.Now()
+		logged = time.Now()
+		nodes  int
+	)
+	if genesis := core.GetHeader(p.db, core.GetCanonicalHash(p.db, 0), 0); genesis != nil && genesis.Root != root {
+		if ok, _ := p.db.Has(genesis.Root[:]); ok {
+			roots = append(roots, genesis.Root)
+		}
+	}
+	for i, root := range roots {
+		statedb, err := state.New(root, state.NewDatabase(p.db))
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("%v: %v", errMissingState, err)
+			}
+			continue
+		}
+		it := state.NewNodeIterator(statedb)
+		for it.Next() {
+			if it.Hash == (common.Hash{}) {
+				continue
+			}
+			bloom.add(it.Hash)
+			nodes++
+
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Marking reachable state", "root", root, "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		if it.Error != nil {
+			return nil, it.Error
+		}
+	}
+	log.Info("Marked reachable state", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return bloom, nil
+}
+
+func (p *Pruner) sweep(bloom *stateBloom) error {
+	var (
+		batch   = new(leveldb.Batch)
+		size    int
+		deleted int
+		start   = time.Now()
+		logged  = time.Now()
+	)
+	it := p.db.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || bloom.contains(key) {
+			continue
+		}
+		batch.Delete(common.CopyBytes(key))
+		size += len(key) + len(it.Value())
+		deleted++
+
+		if size >= epvdb.IdealBatchSize {
+			if err := p.db.LDB().Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+			size = 0
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Sweeping stale state", "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := p.db.LDB().Write(batch, nil); err != nil {
+		return err
+	}
+	log.Info("Swept stale state", "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
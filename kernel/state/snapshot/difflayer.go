@@ -0,0 +1,155 @@
+package snapshot
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/epvchain/go-epvchain/public"
+)
+
+type diffLayer struct {
+	parent snapshot
+	root   common.Hash
+	stale  uint32
+
+	destructs map[common.Hash]struct{}
+	accounts  map[common.Hash][]byte
+	storage   map[common.Hash]map[common.Hash][]byte
+	memory    uint64 // Approximate size of the layer content in bytes
+
+	lock sync.RWMutex
+}
+
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	if destructs == nil {
+		destructs = make(map[common.Hash]struct{})
+	}
+	if accounts == nil {
+		accounts = make(map[common.Hash][]byte)
+	}
+	if storage == nil {
+		storage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+	memory := uint64(len(destructs) * common.HashLength)
+	for _, data := range accounts {
+		memory += uint64(common.HashLength + len(data))
+	}
+	for _, slots := range storage {
+		for _, data := range slots {
+			memory += uint64(2*common.HashLength + len(data))
+		}
+	}
+	return &diffLayer{
+		parent:    parent,
+		root:      root,
+		destructs: destructs,
+		accounts:  accounts,
+		storage:   storage,
+		memory:    memory,
+	}
+}
+
+func (dl *diffLayer) Root() common.Hash {
+	return dl.root
+}
+
+func (dl *diffLayer) Parent() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.parent
+}
+
+func (dl *diffLayer) setParent(parent snapshot) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.parent = parent
+}
+
+func (dl *diffLayer) Stale() bool {
+	return atomic.LoadUint32(&dl.stale) != 0
+}
+
+func (dl *diffLayer) markStale() {
+	atomic.StoreUint32(&dl.stale, 1)
+}
+
+func (dl *diffLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.Stale() {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if data, ok := dl.accounts[hash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	if _, ok := dl.destructs[hash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.AccountRLP(hash)
+}
+
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.Stale() {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if slots, ok := dl.storage[accountHash]; ok {
+		if data, ok := slots[storageHash]; ok {
+			dl.lock.RUnlock()
+			return data, nil
+		}
+	}
+	if _, ok := dl.destructs[accountHash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Storage(accountHash, storageHash)
+}
+
+// merge folds the child layer into this one, handing the combined data over to
+// a new layer. The receiver is marked stale, as its content no longer matches
+// its root.
+func (dl *diffLayer) merge(child *diffLayer) *diffLayer {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.markStale()
+
+	for hash := range child.destructs {
+		dl.destructs[hash] = struct{}{}
+		delete(dl.accounts, hash)
+		delete(dl.storage, hash)
+	}
+	for hash, data := range child.accounts {
+		dl.accounts[hash] = data
+	}
+	for accountHash, slots := range child.storage {
+		merged, ok := dl.storage[accountHash]
+		if !ok {
+			merged = make(map[common.Hash][]byte, len(slots))
+			dl.storage[accountHash] = merged
+		}
+		for storageHash, data := range slots {
+			merged[storageHash] = data
+		}
+	}
+	return &diffLayer{
+		parent:    dl.parent,
+		root:      child.root,
+		destructs: dl.destructs,
+		accounts:  dl.accounts,
+		storage:   dl.storage,
+		memory:    dl.memory + child.memory,
+	}
+}
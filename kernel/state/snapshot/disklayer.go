@@ -0,0 +1,281 @@
+package snapshot
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/process"
+	"github.com/epvchain/go-epvchain/fast"
+)
+
+var (
+	snapshotRootKey      = []byte("SnapshotRoot")
+	snapshotGeneratorKey = []byte("SnapshotGenerator")
+
+	snapshotAccountPrefix = []byte("a")
+	snapshotStoragePrefix = []byte("o")
+)
+
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotAccountPrefix...), hash[:]...)
+}
+
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(storageSnapshotsKey(accountHash), storageHash[:]...)
+}
+
+func storageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(append([]byte{}, snapshotStoragePrefix...), accountHash[:]...)
+}
+
+type diskLayer struct {
+	diskdb epvdb.Database
+	triedb *trie.Database
+	root   common.Hash
+	stale  bool
+
+	genMarker []byte
+	genAbort  chan chan struct{}
+	genPinned bool // Whether root is referenced in triedb while generating
+
+	lock sync.RWMutex
+}
+
+type generatorRLP struct {
+	Root   common.Hash
+	Marker []byte
+}
+
+func loadSnapshot(diskdb epvdb.Database, triedb *trie.Database, root common.Hash) (*diskLayer, error) {
+	blob, _ := diskdb.Get(snapshotRootKey)
+	if len(blob) != common.HashLength {
+		return nil, errSnapshotMissing
+	}
+	if base := common.BytesToHash(blob); base != root {
+		return nil, errSnapshotRoot
+	}
+	base := &diskLayer{diskdb: diskdb, triedb: triedb, root: root}
+
+	if blob, _ := diskdb.Get(snapshotGeneratorKey); len(blob) > 0 {
+		var gen generatorRLP
+		if err := rlp.DecodeBytes(blob, &gen); err != nil {
+			return nil, err
+		}
+		if gen.Root != root {
+			return nil, errSnapshotRoot
+		}
+		base.genMarker = append([]byte{}, gen.Marker...)
+		base.startGeneration()
+	}
+	return base, nil
+}
+
+func generateSnapshot(diskdb epvdb.Database, triedb *trie.Database, root common.Hash) *diskLayer {
+	batch := diskdb.NewBatch()
+	for _, prefix := range [][]byte{snapshotAccountPrefix, snapshotStoragePrefix} {
+		iterate(diskdb, prefix, func(key, value []byte) bool {
+			if isSnapshotKey(key) {
+				batch.Delete(key)
+			}
+			if batch.ValueSize() >= epvdb.IdealBatchSize {
+				batch.Write()
+				batch.Reset()
+			}
+			return true
+		})
+	}
+	blob, _ := rlp.EncodeToBytes(&generatorRLP{Root: root, Marker: []byte{}})
+	batch.Put(snapshotGeneratorKey, blob)
+	batch.Put(snapshotRootKey, root[:])
+	batch.Write()
+
+	base := &diskLayer{diskdb: diskdb, triedb: triedb, root: root, genMarker: []byte{}}
+	base.startGeneration()
+	return base
+}
+
+func isSnapshotKey(key []byte) bool {
+	switch {
+	case bytes.HasPrefix(key, snapshotAccountPrefix):
+		return len(key) == len(snapshotAccountPrefix)+common.HashLength
+	case bytes.HasPrefix(key, snapshotStoragePrefix):
+		return len(key) == len(snapshotStoragePrefix)+2*common.HashLength
+	}
+	return false
+}
+
+// iterate walks the database entries with the given prefix in key order until
+// the callback returns false.
+func iterate(db epvdb.Database, prefix []byte, fn func(key, value []byte) bool) error {
+	switch db := db.(type) {
+	case *epvdb.LDBDatabase:
+		it := db.NewIterator()
+		defer it.Release()
+
+		for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+			if !fn(common.CopyBytes(it.Key()), common.CopyBytes(it.Value())) {
+				break
+			}
+		}
+		return it.Error()
+
+	case *epvdb.MemDatabase:
+		var keys [][]byte
+		for _, key := range db.Keys() {
+			if bytes.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		for _, key := range keys {
+			value, err := db.Get(key)
+			if err != nil {
+				continue
+			}
+			if !fn(key, value) {
+				break
+			}
+		}
+		return nil
+	}
+	return errSnapshotMissing
+}
+
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+func (dl *diskLayer) generating() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker != nil
+}
+
+func (dl *diskLayer) running() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genAbort != nil
+}
+
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || (len(dl.genMarker) > 0 && bytes.Compare(hash[:], dl.genMarker) <= 0)
+}
+
+func (dl *diskLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(accountSnapshotKey(hash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(storageSnapshotKey(accountHash, storageHash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// flatten writes the content of a diff layer directly on top of this disk
+// layer, returning the new disk layer it becomes. It must not be called while
+// the generator runs; a paused generation is carried over to the new layer
+// and resumed from its marker.
+func (dl *diskLayer) flatten(diff *diffLayer) (*diskLayer, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	batch := dl.diskdb.NewBatch()
+	flush := func() error {
+		if batch.ValueSize() < epvdb.IdealBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for hash := range diff.destructs {
+		batch.Delete(accountSnapshotKey(hash))
+		var err error
+		iterate(dl.diskdb, storageSnapshotsKey(hash), func(key, value []byte) bool {
+			batch.Delete(key)
+			err = flush()
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for hash, data := range diff.accounts {
+		batch.Put(accountSnapshotKey(hash), data)
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	for accountHash, slots := range diff.storage {
+		for storageHash, data := range slots {
+			if len(data) == 0 {
+				batch.Delete(storageSnapshotKey(accountHash, storageHash))
+			} else {
+				batch.Put(storageSnapshotKey(accountHash, storageHash), data)
+			}
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if dl.genMarker != nil {
+		blob, err := rlp.EncodeToBytes(&generatorRLP{Root: diff.root, Marker: dl.genMarker})
+		if err != nil {
+			return nil, err
+		}
+		batch.Put(snapshotGeneratorKey, blob)
+	}
+	batch.Put(snapshotRootKey, diff.root[:])
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	dl.stale = true
+
+	disk := &diskLayer{diskdb: dl.diskdb, triedb: dl.triedb, root: diff.root, genMarker: common.CopyBytes(dl.genMarker)}
+	if disk.genMarker != nil {
+		disk.startGeneration()
+	}
+	return disk, nil
+}
@@ -0,0 +1,177 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/process"
+	"github.com/epvchain/go-epvchain/fast"
+)
+
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+type Account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// startGeneration runs the generator in the background. The root is kept
+// referenced in the trie database meanwhile, so that the chain's trie garbage
+// collection cannot drop it from under the generator.
+func (dl *diskLayer) startGeneration() {
+	dl.genAbort = make(chan chan struct{})
+	dl.genPinned = dl.triedb.ReferenceCached(dl.root)
+	go dl.generate(dl.genAbort)
+}
+
+// stopGeneration pauses a running generator at its last checkpoint. It
+// reports whether the generator is no longer running.
+func (dl *diskLayer) stopGeneration() bool {
+	dl.lock.RLock()
+	abort := dl.genAbort
+	dl.lock.RUnlock()
+
+	if abort == nil {
+		return true
+	}
+	done := make(chan struct{})
+	select {
+	case abort <- done:
+		<-done
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func (dl *diskLayer) generate(abort chan chan struct{}) {
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts int
+		slots    int
+		batch    = dl.diskdb.NewBatch()
+		ok       bool
+	)
+	dl.lock.RLock()
+	marker := common.CopyBytes(dl.genMarker)
+	dl.lock.RUnlock()
+
+	defer func() {
+		dl.lock.Lock()
+		dl.genAbort = nil
+		if !ok {
+			dl.stale = true
+		}
+		pinned := dl.genPinned
+		dl.genPinned = false
+		dl.lock.Unlock()
+
+		if pinned {
+			dl.triedb.Dereference(dl.root, common.Hash{})
+		}
+	}()
+	accTrie, err := trie.New(dl.root, dl.triedb)
+	if err != nil {
+		log.Error("Failed to open account trie for snapshot generation", "root", dl.root, "err", err)
+		return
+	}
+	checkpoint := func(last []byte) error {
+		blob, err := rlp.EncodeToBytes(&generatorRLP{Root: dl.root, Marker: last})
+		if err != nil {
+			return err
+		}
+		batch.Put(snapshotGeneratorKey, blob)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+
+		dl.lock.Lock()
+		dl.genMarker = common.CopyBytes(last)
+		dl.lock.Unlock()
+		return nil
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(marker))
+	for it.Next() {
+		if len(marker) > 0 && bytes.Compare(it.Key, marker) <= 0 {
+			continue
+		}
+		accountHash := common.BytesToHash(it.Key)
+		batch.Put(accountSnapshotKey(accountHash), common.CopyBytes(it.Value))
+		accounts++
+
+		var account Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			log.Error("Invalid account encountered during snapshot generation", "hash", accountHash, "err", err)
+			return
+		}
+		if account.Root != emptyRoot {
+			storeTrie, err := trie.New(account.Root, dl.triedb)
+			if err != nil {
+				log.Error("Failed to open storage trie for snapshot generation", "root", account.Root, "err", err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				batch.Put(storageSnapshotKey(accountHash, common.BytesToHash(storeIt.Key)), common.CopyBytes(storeIt.Value))
+				slots++
+
+				if batch.ValueSize() > epvdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						log.Error("Failed to write state snapshot", "err", err)
+						return
+					}
+					batch.Reset()
+				}
+			}
+			if storeIt.Err != nil {
+				log.Error("Failed to iterate storage trie for snapshot generation", "root", account.Root, "err", storeIt.Err)
+				return
+			}
+		}
+		if batch.ValueSize() > epvdb.IdealBatchSize {
+			if err := checkpoint(accountHash[:]); err != nil {
+				log.Error("Failed to write state snapshot", "err", err)
+				return
+			}
+			marker = accountHash[:]
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "root", dl.root, "at", accountHash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		select {
+		case done := <-abort:
+			if err := checkpoint(accountHash[:]); err != nil {
+				log.Error("Failed to write state snapshot", "err", err)
+			}
+			log.Info("Paused state snapshot generation", "root", dl.root, "at", accountHash)
+			ok = true
+			close(done)
+			return
+		default:
+		}
+	}
+	if it.Err != nil {
+		log.Error("Failed to iterate account trie for snapshot generation", "root", dl.root, "err", it.Err)
+		return
+	}
+	batch.Delete(snapshotGeneratorKey)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write state snapshot", "err", err)
+		return
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+	ok = true
+
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,206 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/book"
+	"github.com/epvchain/go-epvchain/fast"
+)
+
+// aggregatorMemoryLimit is the size the flattened diff may grow to while the
+// snapshot is being generated, before it is written to disk regardless.
+const aggregatorMemoryLimit = 4 * 1024 * 1024
+
+var (
+	ErrSnapshotStale = errors.New("snapshot stale")
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	errSnapshotMissing = errors.New("snapshot missing")
+	errSnapshotRoot    = errors.New("snapshot root mismatch")
+)
+
+type Snapshot interface {
+	Root() common.Hash
+
+	AccountRLP(hash common.Hash) ([]byte, error)
+
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+type snapshot interface {
+	Snapshot
+
+	Parent() snapshot
+
+	Stale() bool
+}
+
+type Tree struct {
+	diskdb epvdb.Database
+	triedb *trie.Database
+	layers map[common.Hash]snapshot
+	lock   sync.RWMutex
+}
+
+func New(diskdb epvdb.Database, triedb *trie.Database, root common.Hash) *Tree {
+	base, err := loadSnapshot(diskdb, triedb, root)
+	if err != nil {
+		log.Warn("Rebuilding state snapshot", "root", root, "reason", err)
+		base = generateSnapshot(diskdb, triedb, root)
+	}
+	return &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: map[common.Hash]snapshot{root: base},
+	}
+}
+
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if snap, ok := t.layers[root]; ok {
+		return snap
+	}
+	return nil
+}
+
+func (t *Tree) Update(root common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if root == parentRoot {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok {
+		return nil
+	}
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent [%#x] snapshot missing", parentRoot)
+	}
+	t.layers[root] = newDiffLayer(parent, root, destructs, accounts, storage)
+	return nil
+}
+
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	diff, ok := snap.(*diffLayer)
+	if !ok {
+		return nil
+	}
+	var child *diffLayer
+	for i := 0; i < layers; i++ {
+		parent, ok := diff.Parent().(*diffLayer)
+		if !ok {
+			return nil
+		}
+		child, diff = diff, parent
+	}
+	if disk := t.bottom(diff); disk.Stale() {
+		log.Warn("State snapshot unusable, disabling", "root", disk.Root())
+		for root, layer := range t.layers {
+			if diff, ok := layer.(*diffLayer); ok {
+				diff.markStale()
+			}
+			delete(t.layers, root)
+		}
+		return nil
+	}
+	var (
+		chain = []*diffLayer{diff}
+		base  *diskLayer
+	)
+	for {
+		parent := chain[len(chain)-1].Parent()
+		if disk, ok := parent.(*diskLayer); ok {
+			base = disk
+			break
+		}
+		chain = append(chain, parent.(*diffLayer))
+	}
+	flat := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		flat = flat.merge(chain[i])
+	}
+	for _, layer := range chain {
+		layer.markStale()
+	}
+	var bottom snapshot = flat
+	if !base.generating() || ((!base.running() || flat.memory >= aggregatorMemoryLimit) && base.stopGeneration()) {
+		disk, err := base.flatten(flat)
+		if err != nil {
+			return err
+		}
+		bottom = disk
+	}
+	if child != nil {
+		child.setParent(bottom)
+	}
+	t.layers[bottom.Root()] = bottom
+
+	for root, layer := range t.layers {
+		if !descends(layer, bottom) {
+			if diff, ok := layer.(*diffLayer); ok {
+				diff.markStale()
+			}
+			delete(t.layers, root)
+		}
+	}
+	return nil
+}
+
+func descends(layer snapshot, ancestor snapshot) bool {
+	for layer != nil {
+		if layer == ancestor {
+			return true
+		}
+		if layer.Stale() {
+			return false
+		}
+		layer = layer.Parent()
+	}
+	return false
+}
+
+// Journal flattens every layer up to the given root into the persisted snapshot
+// and pauses background generation, so that the snapshot can be reused once
+// the chain restarts from that root.
+func (t *Tree) Journal(root common.Hash) error {
+	if disk := t.disk(); disk != nil {
+		disk.stopGeneration()
+	}
+	err := t.Cap(root, 0)
+
+	if disk := t.disk(); disk != nil {
+		disk.stopGeneration()
+	}
+	return err
+}
+
+func (t *Tree) bottom(layer snapshot) *diskLayer {
+	for layer.Parent() != nil {
+		layer = layer.Parent()
+	}
+	return layer.(*diskLayer)
+}
+
+func (t *Tree) disk() *diskLayer {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	for _, layer := range t.layers {
+		return t.bottom(layer)
+	}
+	return nil
+}
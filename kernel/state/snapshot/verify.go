@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/data"
+	"github.com/epvchain/go-epvchain/process"
+	"github.com/epvchain/go-epvchain/fast"
+)
+
+var errSnapshotGenerating = errors.New("snapshot is still being generated")
+
+// Verify recomputes the state root from the flat accounts and storage slots of
+// the persisted snapshot and checks it against the expected root.
+func Verify(diskdb epvdb.Database, root common.Hash) error {
+	blob, _ := diskdb.Get(snapshotRootKey)
+	if len(blob) != common.HashLength {
+		return errSnapshotMissing
+	}
+	if base := common.BytesToHash(blob); base != root {
+		return fmt.Errorf("%v: have %x, want %x", errSnapshotRoot, base, root)
+	}
+	if blob, _ := diskdb.Get(snapshotGeneratorKey); len(blob) > 0 {
+		return errSnapshotGenerating
+	}
+	memdb, _ := epvdb.NewMemDatabase()
+	accTrie, _ := trie.New(common.Hash{}, trie.NewDatabase(memdb))
+
+	var err error
+	iterate(diskdb, snapshotAccountPrefix, func(key, value []byte) bool {
+		if !isSnapshotKey(key) {
+			return true
+		}
+		accountHash := common.BytesToHash(key[len(snapshotAccountPrefix):])
+
+		var account Account
+		if err = rlp.DecodeBytes(value, &account); err != nil {
+			return false
+		}
+		have, serr := storageRoot(diskdb, accountHash)
+		if serr != nil {
+			err = serr
+			return false
+		}
+		if have != account.Root {
+			err = fmt.Errorf("storage root mismatch for account %x: have %x, want %x", accountHash, have, account.Root)
+			return false
+		}
+		accTrie.Update(accountHash[:], value)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if have := accTrie.Hash(); have != root {
+		return fmt.Errorf("%v: computed %x, want %x", errSnapshotRoot, have, root)
+	}
+	return nil
+}
+
+func storageRoot(diskdb epvdb.Database, accountHash common.Hash) (common.Hash, error) {
+	memdb, _ := epvdb.NewMemDatabase()
+	storeTrie, _ := trie.New(common.Hash{}, trie.NewDatabase(memdb))
+
+	prefix := storageSnapshotsKey(accountHash)
+	err := iterate(diskdb, prefix, func(key, value []byte) bool {
+		if isSnapshotKey(key) {
+			storeTrie.Update(key[len(prefix):], value)
+		}
+		return true
+	})
+	return storeTrie.Hash(), err
+}
@@ -54,6 +54,7 @@ type stateObject struct {
 	dirtyStorage  Storage 
 
 	dirtyCode bool 
+	fresh     bool 
 	suicided  bool
 	touched   bool
 	deleted   bool
@@ -138,11 +139,41 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 		return value
 	}
 
+	var (
+		enc []byte
+		err error
+	)
+	if snap := self.db.snap; snap != nil && !self.fresh {
+		enc, err = snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if self.db.snap == nil || self.fresh || err != nil {
+		if enc, err = self.getTrie(db).TryGet(key[:]); err != nil {
+			self.setError(err)
+			return common.Hash{}
+		}
+	}
+	value = self.decodeState(enc)
+	if (value != common.Hash{}) {
+		self.cachedStorage[key] = value
+	}
+	return value
+}
+
+// GetCommittedState returns the value of a storage slot ignoring any writes
+// not yet flushed into the storage trie.
+func (self *stateObject) GetCommittedState(db Database, key common.Hash) common.Hash {
+	if _, dirty := self.dirtyStorage[key]; !dirty {
+		return self.GetState(db, key)
+	}
 	enc, err := self.getTrie(db).TryGet(key[:])
 	if err != nil {
 		self.setError(err)
 		return common.Hash{}
 	}
+	return self.decodeState(enc)
+}
+
+func (self *stateObject) decodeState(enc []byte) (value common.Hash) {
 	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
 		if err != nil {
@@ -150,9 +181,6 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 		}
 		value.SetBytes(content)
 	}
-	if (value != common.Hash{}) {
-		self.cachedStorage[key] = value
-	}
 	return value
 }
 
@@ -175,17 +203,47 @@ func (self *stateObject) setState(key, value common.Hash) {
 	}
 }
 
+// SetStorage replaces the whole storage of the object with the given slots.
+// The change is not journalled, it is meant for throwaway call state only.
+func (self *stateObject) SetStorage(storage map[common.Hash]common.Hash) {
+	self.trie = nil
+	self.data.Root = common.Hash{}
+	self.fresh = true
+	self.cachedStorage = make(Storage)
+	self.dirtyStorage = make(Storage)
+	for key, value := range storage {
+		self.setState(key, value)
+	}
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 func (self *stateObject) updateTrie(db Database) Trie {
+	var storage map[common.Hash][]byte
+	if self.db.snap != nil && len(self.dirtyStorage) > 0 {
+		if storage = self.db.snapStorage[self.addrHash]; storage == nil {
+			storage = make(map[common.Hash][]byte)
+			self.db.snapStorage[self.addrHash] = storage
+		}
+	}
 	tr := self.getTrie(db)
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
+			if storage != nil {
+				storage[crypto.Keccak256Hash(key[:])] = nil
+			}
 			continue
 		}
 
 		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 		self.setError(tr.TryUpdate(key[:], v))
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v
+		}
 	}
 	return tr
 }
@@ -254,6 +312,7 @@ func (self *stateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 	stateObject.cachedStorage = self.dirtyStorage.Copy()
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
+	stateObject.fresh = self.fresh
 	stateObject.deleted = self.deleted
 	return stateObject
 }
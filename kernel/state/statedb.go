@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/state/snapshot"
 	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/code"
 	"github.com/epvchain/go-epvchain/book"
@@ -20,6 +21,10 @@ type revision struct {
 	journalIndex int
 }
 
+// snapshotLayers is the number of diff layers kept on top of the persisted
+// snapshot, matching the number of recent tries the chain keeps in memory.
+const snapshotLayers = 128
+
 var (
 
 	emptyState = crypto.Keccak256Hash(nil)
@@ -31,6 +36,12 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
 
@@ -45,6 +56,8 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	accessList *accessList
+
 	journal        journal
 	validRevisions []revision
 	nextRevisionId int
@@ -53,18 +66,38 @@ type StateDB struct {
 }
 
 func New(root common.Hash, db Database) (*StateDB, error) {
+	return NewWithSnapshot(root, db, nil)
+}
+
+func NewWithSnapshot(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
 	tr, err := db.OpenTrie(root)
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                db,
 		trie:              tr,
+		snaps:             snaps,
 		stateObjects:      make(map[common.Address]*stateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
-	}, nil
+		accessList:        newAccessList(),
+	}
+	sdb.resetSnapshot(root)
+	return sdb, nil
+}
+
+func (self *StateDB) resetSnapshot(root common.Hash) {
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+	if self.snaps == nil {
+		return
+	}
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 }
 
 func (self *StateDB) setError(err error) {
@@ -83,6 +116,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 		return err
 	}
 	self.trie = tr
+	self.resetSnapshot(root)
 	self.stateObjects = make(map[common.Address]*stateObject)
 	self.stateObjectsDirty = make(map[common.Address]struct{})
 	self.thash = common.Hash{}
@@ -91,6 +125,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.accessList = newAccessList()
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -136,6 +171,14 @@ func (self *StateDB) AddRefund(gas uint64) {
 	self.refund += gas
 }
 
+func (self *StateDB) SubRefund(gas uint64) {
+	self.journal = append(self.journal, refundChange{prev: self.refund})
+	if gas > self.refund {
+		panic("refund counter below zero")
+	}
+	self.refund -= gas
+}
+
 func (self *StateDB) Exist(addr common.Address) bool {
 	return self.getStateObject(addr) != nil
 }
@@ -201,6 +244,16 @@ func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 	return common.Hash{}
 }
 
+// GetCommittedState returns the value of a storage slot as it was before the
+// current transaction modified it.
+func (self *StateDB) GetCommittedState(a common.Address, b common.Hash) common.Hash {
+	stateObject := self.getStateObject(a)
+	if stateObject != nil {
+		return stateObject.GetCommittedState(self.db, b)
+	}
+	return common.Hash{}
+}
+
 func (self *StateDB) Database() Database {
 	return self.db
 }
@@ -214,6 +267,33 @@ func (self *StateDB) StorageTrie(a common.Address) Trie {
 	return cpy.updateTrie(self.db)
 }
 
+// proofList collects the nodes of a Merkle proof in root to leaf order.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns the Merkle proof of the account at a in the state trie.
+func (self *StateDB) GetProof(a common.Address) ([][]byte, error) {
+	var proof proofList
+	err := self.trie.Prove(crypto.Keccak256(a.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
+// GetStorageProof returns the Merkle proof of the storage slot key in the
+// storage trie of the account at a. Missing accounts have an empty proof.
+func (self *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byte, error) {
+	var proof proofList
+	trie := self.StorageTrie(a)
+	if trie == nil {
+		return [][]byte(proof), nil
+	}
+	err := trie.Prove(crypto.Keccak256(key.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
@@ -264,6 +344,14 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 	}
 }
 
+// SetStorage replaces the entire storage of the account at addr.
+func (self *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetStorage(storage)
+	}
+}
+
 func (self *StateDB) Suicide(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
@@ -287,12 +375,22 @@ func (self *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	self.setError(self.trie.TryUpdate(addr[:], data))
+
+	if self.snap != nil {
+		self.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 func (self *StateDB) deleteStateObject(stateObject *stateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.setError(self.trie.TryDelete(addr[:]))
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(self.snapAccounts, stateObject.addrHash)
+		delete(self.snapStorage, stateObject.addrHash)
+	}
 }
 
 func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObject) {
@@ -304,10 +402,22 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	enc, err := self.trie.TryGet(addr[:])
-	if len(enc) == 0 {
-		self.setError(err)
-		return nil
+	var (
+		enc []byte
+		err error
+	)
+	if self.snap != nil {
+		enc, err = self.snap.AccountRLP(crypto.Keccak256Hash(addr[:]))
+		if err == nil && len(enc) == 0 {
+			return nil
+		}
+	}
+	if self.snap == nil || err != nil {
+		enc, err = self.trie.TryGet(addr[:])
+		if len(enc) == 0 {
+			self.setError(err)
+			return nil
+		}
 	}
 	var data Account
 	if err := rlp.DecodeBytes(enc, &data); err != nil {
@@ -339,6 +449,7 @@ func (self *StateDB) MarkStateObjectDirty(addr common.Address) {
 func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObject) {
 	prev = self.getStateObject(addr)
 	newobj = newObject(self, addr, Account{}, self.MarkStateObjectDirty)
+	newobj.fresh = true
 	newobj.setNonce(0) 
 	if prev == nil {
 		self.journal = append(self.journal, createObjectChange{account: &addr})
@@ -383,12 +494,15 @@ func (self *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		db:                self.db,
 		trie:              self.db.CopyTrie(self.trie),
+		snaps:             self.snaps,
+		snap:              self.snap,
 		stateObjects:      make(map[common.Address]*stateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		refund:            self.refund,
 		logs:              make(map[common.Hash][]*types.Log, len(self.logs)),
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte),
+		accessList:        self.accessList.Copy(),
 	}
 
 	for addr := range self.stateObjectsDirty {
@@ -402,6 +516,23 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, data := range self.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, slots := range self.snapStorage {
+			state.snapStorage[hash] = make(map[common.Hash][]byte, len(slots))
+			for key, data := range slots {
+				state.snapStorage[hash][key] = data
+			}
+		}
+	}
 	return state
 }
 
@@ -519,5 +650,17 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+
+	if err == nil && s.snap != nil {
+		if parent := s.snap.Root(); parent != root {
+			if err := s.snaps.Update(root, parent, s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+				log.Debug("Failed to update state snapshot", "from", parent, "to", root, "err", err)
+			}
+			if err := s.snaps.Cap(root, snapshotLayers); err != nil {
+				log.Debug("Failed to cap state snapshot", "root", root, "layers", snapshotLayers, "err", err)
+			}
+		}
+		s.resetSnapshot(root)
+	}
 	return root, err
 }
@@ -49,13 +49,15 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		allLogs = append(allLogs, receipt.Logs...)
 	}
 
-	p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts)
+	if _, err := p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts); err != nil {
+		return nil, nil, 0, err
+	}
 
 	return receipts, allLogs, *usedGas, nil
 }
 
 func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error) {
-	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
+	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number), header.BaseFee)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -78,6 +80,7 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	*usedGas += gas
 
 	receipt := types.NewReceipt(root, failed, *usedGas)
+	receipt.Type = tx.Type()
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = gas
 
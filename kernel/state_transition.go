@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/kernel/vm"
 	"github.com/epvchain/go-epvchain/book"
 	"github.com/epvchain/go-epvchain/content"
@@ -21,6 +22,8 @@ type StateTransition struct {
 	msg        Message
 	gas        uint64
 	gasPrice   *big.Int
+	gasFeeCap  *big.Int
+	gasTipCap  *big.Int
 	initialGas uint64
 	value      *big.Int
 	data       []byte
@@ -34,15 +37,18 @@ type Message interface {
 	To() *common.Address
 
 	GasPrice() *big.Int
+	GasFeeCap() *big.Int
+	GasTipCap() *big.Int
 	Gas() uint64
 	Value() *big.Int
 
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	AccessList() types.AccessList
 }
 
-func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error) {
+func IntrinsicGas(data []byte, accessList types.AccessList, contractCreation, homestead bool) (uint64, error) {
 
 	var gas uint64
 	if contractCreation && homestead {
@@ -71,6 +77,10 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 		}
 		gas += z * params.TxDataZeroGas
 	}
+	if accessList != nil {
+		gas += uint64(len(accessList)) * params.TxAccessListAddressGas
+		gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	}
 	return gas, nil
 }
 
@@ -79,7 +89,9 @@ func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition
 		gp:       gp,
 		evm:      evm,
 		msg:      msg,
-		gasPrice: msg.GasPrice(),
+		gasPrice:  msg.GasPrice(),
+		gasFeeCap: msg.GasFeeCap(),
+		gasTipCap: msg.GasTipCap(),
 		value:    msg.Value(),
 		data:     msg.Data(),
 		state:    evm.StateDB,
@@ -129,7 +141,11 @@ func (st *StateTransition) buyGas() error {
 		sender = st.from()
 	)
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	if state.GetBalance(sender.Address()).Cmp(mgval) < 0 {
+	balanceCheck := mgval
+	if st.gasFeeCap != nil {
+		balanceCheck = new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasFeeCap)
+	}
+	if state.GetBalance(sender.Address()).Cmp(balanceCheck) < 0 {
 		return errInsufficientBalanceForGas
 	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
@@ -154,6 +170,18 @@ func (st *StateTransition) preCheck() error {
 			return ErrNonceTooLow
 		}
 	}
+	if baseFee := st.evm.BaseFee; baseFee != nil && st.evm.ChainConfig().IsBaseFee(st.evm.BlockNumber) {
+		// Calls outside of blocks may leave out the fees entirely.
+		skip := st.evm.Config().NoBaseFee && st.gasFeeCap.Sign() == 0 && st.gasTipCap.Sign() == 0
+		if !skip {
+			if st.gasFeeCap.Cmp(st.gasTipCap) < 0 {
+				return ErrTipAboveFeeCap
+			}
+			if st.gasFeeCap.Cmp(baseFee) < 0 {
+				return ErrFeeCapTooLow
+			}
+		}
+	}
 	return st.buyGas()
 }
 
@@ -167,13 +195,16 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
 	contractCreation := msg.To() == nil
 
-	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
+	gas, err := IntrinsicGas(st.data, msg.AccessList(), contractCreation, homestead)
 	if err != nil {
 		return nil, 0, false, err
 	}
 	if err = st.useGas(gas); err != nil {
 		return nil, 0, false, err
 	}
+	if rules := st.evm.ChainConfig().Rules(st.evm.BlockNumber); rules.IsAccessList {
+		st.state.PrepareAccessList(sender.Address(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
+	}
 
 	var (
 		evm = st.evm
@@ -195,7 +226,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
-	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	st.payFees()
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
@@ -216,6 +247,27 @@ func (st *StateTransition) refundGas() {
 	st.gp.AddGas(st.gas)
 }
 
+// payFees credits the block producer with the fees of the used gas. After the
+// base fee fork the producer only receives the tip, while the base fee part is
+// burned or credited to the configured treasury.
+func (st *StateTransition) payFees() {
+	gasUsed := new(big.Int).SetUint64(st.gasUsed())
+
+	config := st.evm.ChainConfig()
+	if st.evm.BaseFee == nil || !config.IsBaseFee(st.evm.BlockNumber) {
+		st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(gasUsed, st.gasPrice))
+		return
+	}
+	burnt, tip := st.evm.BaseFee, new(big.Int).Sub(st.gasPrice, st.evm.BaseFee)
+	if tip.Sign() < 0 {
+		burnt, tip = st.gasPrice, new(big.Int)
+	}
+	st.state.AddBalance(st.evm.Coinbase, tip.Mul(tip, gasUsed))
+	if config.BaseFeeTreasury != nil {
+		st.state.AddBalance(*config.BaseFeeTreasury, new(big.Int).Mul(gasUsed, burnt))
+	}
+}
+
 func (st *StateTransition) gasUsed() uint64 {
 	return st.initialGas - st.gas
 }
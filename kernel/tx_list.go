@@ -193,9 +193,9 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
+		// A replacement has to bump both the fee cap and the tip, which are
+		// the same value for transactions without dynamic fees.
+		if !bumped(old.GasFeeCap(), tx.GasFeeCap(), priceBump) || !bumped(old.GasTipCap(), tx.GasTipCap(), priceBump) {
 			return false, nil
 		}
 	}
@@ -210,6 +210,11 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	return true, old
 }
 
+func bumped(old, price *big.Int, priceBump uint64) bool {
+	threshold := new(big.Int).Div(new(big.Int).Mul(old, big.NewInt(100+int64(priceBump))), big.NewInt(100))
+	return old.Cmp(price) < 0 && threshold.Cmp(price) <= 0
+}
+
 func (l *txList) Forward(threshold uint64) types.Transactions {
 	return l.txs.Forward(threshold)
 }
@@ -238,6 +243,21 @@ func (l *txList) Filter(costLimit *big.Int, gasLimit uint64) (types.Transactions
 	return removed, invalids
 }
 
+// Underpriced removes the transactions from the first one whose fee cap is
+// below baseFee onwards, as none of them can be included in the next block.
+func (l *txList) Underpriced(baseFee *big.Int) types.Transactions {
+	lowest := uint64(math.MaxUint64)
+	for nonce, tx := range l.txs.items {
+		if nonce < lowest && tx.GasFeeCap().Cmp(baseFee) < 0 {
+			lowest = nonce
+		}
+	}
+	if lowest == math.MaxUint64 {
+		return nil
+	}
+	return l.txs.Filter(func(tx *types.Transaction) bool { return tx.Nonce() >= lowest })
+}
+
 func (l *txList) Cap(threshold int) types.Transactions {
 	return l.txs.Cap(threshold)
 }
@@ -271,10 +291,18 @@ func (l *txList) Flatten() types.Transactions {
 	return l.txs.Flatten()
 }
 
+// cmpPrice orders transactions by their tip first and their fee cap second.
+func cmpPrice(a, b *types.Transaction) int {
+	if c := a.GasTipCap().Cmp(b.GasTipCap()); c != 0 {
+		return c
+	}
+	return a.GasFeeCap().Cmp(b.GasFeeCap())
+}
+
 type priceHeap []*types.Transaction
 
 func (h priceHeap) Len() int           { return len(h) }
-func (h priceHeap) Less(i, j int) bool { return h[i].GasPrice().Cmp(h[j].GasPrice()) < 0 }
+func (h priceHeap) Less(i, j int) bool { return cmpPrice(h[i], h[j]) < 0 }
 func (h priceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
 
 func (h *priceHeap) Push(x interface{}) {
@@ -334,7 +362,7 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			continue
 		}
 
-		if tx.GasPrice().Cmp(threshold) >= 0 {
+		if tx.GasTipCap().Cmp(threshold) >= 0 {
 			save = append(save, tx)
 			break
 		}
@@ -372,7 +400,7 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 		return false
 	}
 	cheapest := []*types.Transaction(*l.items)[0]
-	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
+	return cmpPrice(cheapest, tx) >= 0
 }
 
 func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
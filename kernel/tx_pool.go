@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/agreement/misc"
 	"github.com/epvchain/go-epvchain/kernel/state"
 	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/notice"
@@ -46,6 +47,8 @@ var (
 	ErrNegativeValue = errors.New("negative value")
 
 	ErrOversizedData = errors.New("oversized data")
+
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
 )
 
 var (
@@ -161,7 +164,10 @@ type TxPool struct {
 
 	wg sync.WaitGroup 
 
-	homestead bool
+	homestead  bool
+	accessList bool 
+	dynamicFee bool 
+	baseFee    *big.Int // Base fee of the next block, nil before the base fee fork
 }
 
 func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain blockChain) *TxPool {
@@ -172,7 +178,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:      config,
 		chainconfig: chainconfig,
 		chain:       chain,
-		signer:      types.NewEIP155Signer(chainconfig.ChainId),
+		signer:      types.NewDynamicFeeSigner(chainconfig.ChainId),
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
@@ -344,6 +350,14 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
+	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
+	pool.accessList = pool.chainconfig.IsAccessList(next)
+	pool.dynamicFee = pool.chainconfig.IsBaseFee(next)
+	pool.baseFee = nil
+	if pool.dynamicFee {
+		pool.baseFee = misc.CalcBaseFee(pool.chainconfig, newHead)
+	}
+
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	pool.addTxsLocked(reinject, false)
 
@@ -458,6 +472,15 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 }
 
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	if tx.Type() != types.LegacyTxType && !pool.accessList {
+		return ErrTxTypeNotSupported
+	}
+	if tx.Type() == types.DynamicFeeTxType && !pool.dynamicFee {
+		return ErrTxTypeNotSupported
+	}
+	if tx.GasFeeCap().Cmp(tx.GasTipCap()) < 0 {
+		return ErrTipAboveFeeCap
+	}
 
 	if tx.Size() > 32*1024 {
 		return ErrOversizedData
@@ -477,7 +500,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	}
 
 	local = local || pool.locals.contains(from) 
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && pool.gasPrice.Cmp(tx.GasTipCap()) > 0 {
 		return ErrUnderpriced
 	}
 
@@ -488,7 +511,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead)
 	if err != nil {
 		return err
 	}
@@ -794,7 +817,15 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			queuedNofundsCounter.Inc(1)
 		}
 
-		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
+		ready := list.Ready(pool.pendingState.GetNonce(addr))
+		for i, tx := range ready {
+			if pool.baseFee != nil && tx.GasFeeCap().Cmp(pool.baseFee) < 0 {
+				// Keep the rest queued until the base fee drops below the fee cap
+				for _, tx := range ready[i:] {
+					list.Add(tx, pool.config.PriceBump)
+				}
+				break
+			}
 			hash := tx.Hash()
 			log.Trace("Promoting queued transaction", "hash", hash)
 			pool.promoteTx(addr, hash, tx)
@@ -946,6 +977,13 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Demoting pending transaction", "hash", hash)
 			pool.enqueueTx(hash, tx)
 		}
+		if pool.baseFee != nil {
+			for _, tx := range list.Underpriced(pool.baseFee) {
+				hash := tx.Hash()
+				log.Trace("Demoting underpriced pending transaction", "hash", hash)
+				pool.enqueueTx(hash, tx)
+			}
+		}
 
 		if list.Len() > 0 && list.txs.Get(nonce) == nil {
 			for _, tx := range list.Cap(0) {
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/code/sha3"
+	"github.com/epvchain/go-epvchain/process"
+)
+
+// Transaction types of the EIP-2718 envelope. Legacy transactions are encoded
+// as a plain RLP list, typed ones as the type byte followed by their payload.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01
+)
+
+type AccessList []AccessTuple
+
+// AccessTuple is an address together with the storage slots of it that a
+// transaction declares it will access.
+type AccessTuple struct {
+	Address     common.Address `json:"address"     gencodec:"required"`
+	StorageKeys []common.Hash  `json:"storageKeys" gencodec:"required"`
+}
+
+func (al AccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return sum
+}
+
+func (al AccessList) copy() AccessList {
+	if al == nil {
+		return nil
+	}
+	cpy := make(AccessList, len(al))
+	for i, tuple := range al {
+		cpy[i] = AccessTuple{Address: tuple.Address, StorageKeys: append([]common.Hash{}, tuple.StorageKeys...)}
+	}
+	return cpy
+}
+
+// accessListTxRLP is the consensus payload of an access list transaction.
+type accessListTxRLP struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	AccessList   AccessList
+	V, R, S      *big.Int
+}
+
+func (d *txdata) accessListRLP() *accessListTxRLP {
+	return &accessListTxRLP{
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		Price:        d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (d *txdata) setAccessListRLP(dec *accessListTxRLP) {
+	*d = txdata{
+		Type:         AccessListTxType,
+		ChainID:      dec.ChainID,
+		AccountNonce: dec.AccountNonce,
+		Price:        dec.Price,
+		GasLimit:     dec.GasLimit,
+		Recipient:    dec.Recipient,
+		Amount:       dec.Amount,
+		Payload:      dec.Payload,
+		AccessList:   dec.AccessList,
+		V:            dec.V,
+		R:            dec.R,
+		S:            dec.S,
+	}
+}
+
+// prefixedRlpHash hashes the type byte followed by the RLP encoding of x.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
@@ -60,6 +60,31 @@ type Header struct {
 	Extra       []byte         `json:"extraData"        gencodec:"required"`
 	MixDigest   common.Hash    `json:"mixHash"          gencodec:"required"`
 	Nonce       BlockNonce     `json:"nonce"            gencodec:"required"`
+
+	// BaseFee is only present on headers after the base fee fork.
+	BaseFee *big.Int `json:"baseFeePerGas,omitempty"`
+}
+
+// extheader is the consensus encoding of a header. The base fee is
+// appended as an optional trailing element so that headers created
+// before the fork keep their original encoding and hash.
+type extheader struct {
+	ParentHash  common.Hash
+	UncleHash   common.Hash
+	Coinbase    common.Address
+	Root        common.Hash
+	TxHash      common.Hash
+	ReceiptHash common.Hash
+	Bloom       Bloom
+	Difficulty  *big.Int
+	Number      *big.Int
+	GasLimit    uint64
+	GasUsed     uint64
+	TimeMS      *big.Int
+	Extra       []byte
+	MixDigest   common.Hash
+	Nonce       BlockNonce
+	Optional    []*big.Int `rlp:"tail"`
 }
 
 type headerMarshaling struct {
@@ -69,6 +94,7 @@ type headerMarshaling struct {
 	GasUsed    hexutil.Uint64
 	TimeMS       *hexutil.Big
 	Extra      hexutil.Bytes
+	BaseFee    *hexutil.Big
 	Hash       common.Hash `json:"hash"`
 }
 
@@ -77,7 +103,7 @@ func (h *Header) Hash() common.Hash {
 }
 
 func (h *Header) HashNoNonce() common.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		h.ParentHash,
 		h.UncleHash,
 		h.Coinbase,
@@ -91,7 +117,66 @@ func (h *Header) HashNoNonce() common.Hash {
 		h.GasUsed,
 		h.TimeMS,
 		h.Extra,
-	})
+	}
+	if h.BaseFee != nil {
+		fields = append(fields, h.BaseFee)
+	}
+	return rlpHash(fields)
+}
+
+func (h *Header) EncodeRLP(w io.Writer) error {
+	enc := &extheader{
+		ParentHash:  h.ParentHash,
+		UncleHash:   h.UncleHash,
+		Coinbase:    h.Coinbase,
+		Root:        h.Root,
+		TxHash:      h.TxHash,
+		ReceiptHash: h.ReceiptHash,
+		Bloom:       h.Bloom,
+		Difficulty:  h.Difficulty,
+		Number:      h.Number,
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		TimeMS:      h.TimeMS,
+		Extra:       h.Extra,
+		MixDigest:   h.MixDigest,
+		Nonce:       h.Nonce,
+	}
+	if h.BaseFee != nil {
+		enc.Optional = []*big.Int{h.BaseFee}
+	}
+	return rlp.Encode(w, enc)
+}
+
+func (h *Header) DecodeRLP(s *rlp.Stream) error {
+	var dec extheader
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.Optional) > 1 {
+		return fmt.Errorf("rlp: too many header fields (%d optional)", len(dec.Optional))
+	}
+	*h = Header{
+		ParentHash:  dec.ParentHash,
+		UncleHash:   dec.UncleHash,
+		Coinbase:    dec.Coinbase,
+		Root:        dec.Root,
+		TxHash:      dec.TxHash,
+		ReceiptHash: dec.ReceiptHash,
+		Bloom:       dec.Bloom,
+		Difficulty:  dec.Difficulty,
+		Number:      dec.Number,
+		GasLimit:    dec.GasLimit,
+		GasUsed:     dec.GasUsed,
+		TimeMS:      dec.TimeMS,
+		Extra:       dec.Extra,
+		MixDigest:   dec.MixDigest,
+		Nonce:       dec.Nonce,
+	}
+	if len(dec.Optional) == 1 {
+		h.BaseFee = dec.Optional[0]
+	}
+	return nil
 }
 
 func (h *Header) Size() common.StorageSize {
@@ -193,6 +278,9 @@ func CopyHeader(h *Header) *Header {
 		cpy.Extra = make([]byte, len(h.Extra))
 		copy(cpy.Extra, h.Extra)
 	}
+	if h.BaseFee != nil {
+		cpy.BaseFee = new(big.Int).Set(h.BaseFee)
+	}
 	return &cpy
 }
 
@@ -254,6 +342,13 @@ func (b *Block) ReceiptHash() common.Hash { return b.header.ReceiptHash }
 func (b *Block) UncleHash() common.Hash   { return b.header.UncleHash }
 func (b *Block) Extra() []byte            { return common.CopyBytes(b.header.Extra) }
 
+func (b *Block) BaseFee() *big.Int {
+	if b.header.BaseFee == nil {
+		return nil
+	}
+	return new(big.Int).Set(b.header.BaseFee)
+}
+
 func (b *Block) Header() *Header { return CopyHeader(b.header) }
 
 func (b *Block) Body() *Body { return &Body{b.transactions, b.uncles} }
@@ -346,7 +441,8 @@ func (h *Header) String() string {
 	Extra:		    %s
 	MixDigest:      %x
 	Nonce:		    %x
-]`, h.Hash(), h.ParentHash, h.UncleHash, h.Coinbase, h.Root, h.TxHash, h.ReceiptHash, h.Bloom, h.Difficulty, h.Number, h.GasLimit, h.GasUsed, h.TimeMS, h.Extra, h.MixDigest, h.Nonce)
+	BaseFee:	    %v
+]`, h.Hash(), h.ParentHash, h.UncleHash, h.Coinbase, h.Root, h.TxHash, h.ReceiptHash, h.Bloom, h.Difficulty, h.Number, h.GasLimit, h.GasUsed, h.TimeMS, h.Extra, h.MixDigest, h.Nonce, h.BaseFee)
 }
 
 type Blocks []*Block
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/epvchain/go-epvchain/public"
+)
+
+// DynamicFeeTxType transactions pay the block base fee plus a tip capped by
+// maxPriorityFeePerGas, never exceeding maxFeePerGas in total per gas.
+const DynamicFeeTxType = 0x02
+
+// dynamicFeeTxRLP is the consensus payload of a dynamic fee transaction. The
+// fee cap is kept in txdata.Price, so the legacy cost accounting applies.
+type dynamicFeeTxRLP struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	GasTipCap    *big.Int
+	GasFeeCap    *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	AccessList   AccessList
+	V, R, S      *big.Int
+}
+
+func (d *txdata) dynamicFeeRLP() *dynamicFeeTxRLP {
+	return &dynamicFeeTxRLP{
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		GasTipCap:    d.GasTipCap,
+		GasFeeCap:    d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (d *txdata) setDynamicFeeRLP(dec *dynamicFeeTxRLP) {
+	*d = txdata{
+		Type:         DynamicFeeTxType,
+		ChainID:      dec.ChainID,
+		GasTipCap:    dec.GasTipCap,
+		AccountNonce: dec.AccountNonce,
+		Price:        dec.GasFeeCap,
+		GasLimit:     dec.GasLimit,
+		Recipient:    dec.Recipient,
+		Amount:       dec.Amount,
+		Payload:      dec.Payload,
+		AccessList:   dec.AccessList,
+		V:            dec.V,
+		R:            dec.R,
+		S:            dec.S,
+	}
+}
+
+// NewDynamicFeeTransaction creates an unsigned dynamic fee transaction. A nil
+// recipient creates a contract.
+func NewDynamicFeeTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasTipCap, gasFeeCap *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := NewAccessListTransaction(chainId, nonce, to, amount, gasLimit, gasFeeCap, data, accessList)
+	tx.data.Type = DynamicFeeTxType
+	tx.data.GasTipCap = new(big.Int)
+	if gasTipCap != nil {
+		tx.data.GasTipCap.Set(gasTipCap)
+	}
+	return tx
+}
@@ -14,6 +14,10 @@ var _ = (*txdataMarshaling)(nil)
 
 func (t txdata) MarshalJSON() ([]byte, error) {
 	type txdata struct {
+		Type         hexutil.Uint64  `json:"type"                 rlp:"-"`
+		ChainID      *hexutil.Big    `json:"chainId,omitempty"    rlp:"-"`
+		AccessList   AccessList      `json:"accessList,omitempty" rlp:"-"`
+		GasTipCap    *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty" rlp:"-"`
 		AccountNonce hexutil.Uint64  `json:"nonce"    gencodec:"required"`
 		Price        *hexutil.Big    `json:"gasPrice" gencodec:"required"`
 		GasLimit     hexutil.Uint64  `json:"gas"      gencodec:"required"`
@@ -26,6 +30,10 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 		Hash         *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var enc txdata
+	enc.Type = hexutil.Uint64(t.Type)
+	enc.ChainID = (*hexutil.Big)(t.ChainID)
+	enc.AccessList = t.AccessList
+	enc.GasTipCap = (*hexutil.Big)(t.GasTipCap)
 	enc.AccountNonce = hexutil.Uint64(t.AccountNonce)
 	enc.Price = (*hexutil.Big)(t.Price)
 	enc.GasLimit = hexutil.Uint64(t.GasLimit)
@@ -41,6 +49,10 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 
 func (t *txdata) UnmarshalJSON(input []byte) error {
 	type txdata struct {
+		Type         *hexutil.Uint64 `json:"type"                 rlp:"-"`
+		ChainID      *hexutil.Big    `json:"chainId,omitempty"    rlp:"-"`
+		AccessList   *AccessList     `json:"accessList,omitempty" rlp:"-"`
+		GasTipCap    *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty" rlp:"-"`
 		AccountNonce *hexutil.Uint64 `json:"nonce"    gencodec:"required"`
 		Price        *hexutil.Big    `json:"gasPrice" gencodec:"required"`
 		GasLimit     *hexutil.Uint64 `json:"gas"      gencodec:"required"`
@@ -56,6 +68,18 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 	if err := json.Unmarshal(input, &dec); err != nil {
 		return err
 	}
+	if dec.Type != nil {
+		t.Type = uint8(*dec.Type)
+	}
+	if dec.ChainID != nil {
+		t.ChainID = (*big.Int)(dec.ChainID)
+	}
+	if dec.AccessList != nil {
+		t.AccessList = *dec.AccessList
+	}
+	if dec.GasTipCap != nil {
+		t.GasTipCap = (*big.Int)(dec.GasTipCap)
+	}
 	if dec.AccountNonce == nil {
 		return errors.New("missing required field 'nonce' for txdata")
 	}
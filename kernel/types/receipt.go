@@ -27,6 +27,7 @@ const (
 )
 
 type Receipt struct {
+	Type uint8 `json:"type,omitempty"`
 
 	PostState         []byte `json:"root"`
 	Status            uint   `json:"status"`
@@ -40,6 +41,7 @@ type Receipt struct {
 }
 
 type receiptMarshaling struct {
+	Type              hexutil.Uint64
 	PostState         hexutil.Bytes
 	Status            hexutil.Uint
 	CumulativeGasUsed hexutil.Uint64
@@ -61,6 +63,10 @@ type receiptStorageRLP struct {
 	ContractAddress   common.Address
 	Logs              []*LogForStorage
 	GasUsed           uint64
+
+	// TxType holds the transaction type of typed receipts. It is a tail so
+	// that legacy receipts keep their original storage encoding.
+	TxType []uint64 `rlp:"tail"`
 }
 
 func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
@@ -73,18 +79,75 @@ func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
 	return r
 }
 
+// EncodeRLP encodes the consensus fields of the receipt. Like transactions,
+// typed receipts are wrapped in an RLP string holding their binary encoding.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+	if r.Type == LegacyTxType {
+		return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+	}
+	enc, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
 }
 
 func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		var dec receiptRLP
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		return r.setFromRLP(LegacyTxType, &dec)
+	default:
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		return r.UnmarshalBinary(b)
+	}
+}
+
+// MarshalBinary returns the canonical encoding of the receipt, which is the
+// value stored in the receipt trie.
+func (r *Receipt) MarshalBinary() ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(&receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+	if err != nil || r.Type == LegacyTxType {
+		return enc, err
+	}
+	return append([]byte{r.Type}, enc...), nil
+}
+
+func (r *Receipt) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		var dec receiptRLP
+		if err := rlp.DecodeBytes(b, &dec); err != nil {
+			return err
+		}
+		return r.setFromRLP(LegacyTxType, &dec)
+	}
+	if len(b) == 0 {
+		return errEmptyTypedTx
+	}
+	if err := checkTxType(b[0]); err != nil {
+		return err
+	}
 	var dec receiptRLP
-	if err := s.Decode(&dec); err != nil {
+	if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
 		return err
 	}
+	return r.setFromRLP(b[0], &dec)
+}
+
+func (r *Receipt) setFromRLP(typ uint8, dec *receiptRLP) error {
 	if err := r.setStatus(dec.PostStateOrStatus); err != nil {
 		return err
 	}
+	r.Type = typ
 	r.CumulativeGasUsed, r.Bloom, r.Logs = dec.CumulativeGasUsed, dec.Bloom, dec.Logs
 	return nil
 }
@@ -142,6 +205,9 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 		Logs:              make([]*LogForStorage, len(r.Logs)),
 		GasUsed:           r.GasUsed,
 	}
+	if r.Type != LegacyTxType {
+		enc.TxType = []uint64{uint64(r.Type)}
+	}
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
 	}
@@ -164,6 +230,9 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	}
 
 	r.TxHash, r.ContractAddress, r.GasUsed = dec.TxHash, dec.ContractAddress, dec.GasUsed
+	if len(dec.TxType) > 0 {
+		r.Type = uint8(dec.TxType[0])
+	}
 	return nil
 }
 
@@ -172,7 +241,7 @@ type Receipts []*Receipt
 func (r Receipts) Len() int { return len(r) }
 
 func (r Receipts) GetRlp(i int) []byte {
-	bytes, err := rlp.EncodeToBytes(r[i])
+	bytes, err := r[i].MarshalBinary()
 	if err != nil {
 		panic(err)
 	}
@@ -3,6 +3,7 @@ package types
 
 import (
 	"container/heap"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -18,8 +19,11 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
-	errNoSigner   = errors.New("missing signing methods")
+	ErrInvalidSig         = errors.New("invalid transaction v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	ErrGasFeeCapTooLow    = errors.New("fee cap less than base fee")
+	errNoSigner           = errors.New("missing signing methods")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
 )
 
 func deriveSigner(V *big.Int) Signer {
@@ -39,6 +43,11 @@ type Transaction struct {
 }
 
 type txdata struct {
+	Type       uint8      `json:"type"                 rlp:"-"`
+	ChainID    *big.Int   `json:"chainId,omitempty"    rlp:"-"`
+	AccessList AccessList `json:"accessList,omitempty" rlp:"-"`
+	GasTipCap  *big.Int   `json:"maxPriorityFeePerGas,omitempty" rlp:"-"`
+
 	AccountNonce uint64          `json:"nonce"    gencodec:"required"`
 	Price        *big.Int        `json:"gasPrice" gencodec:"required"`
 	GasLimit     uint64          `json:"gas"      gencodec:"required"`
@@ -54,6 +63,9 @@ type txdata struct {
 }
 
 type txdataMarshaling struct {
+	Type         hexutil.Uint64
+	ChainID      *hexutil.Big
+	GasTipCap    *hexutil.Big
 	AccountNonce hexutil.Uint64
 	Price        *hexutil.Big
 	GasLimit     hexutil.Uint64
@@ -97,11 +109,34 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 	return &Transaction{data: d}
 }
 
+// NewAccessListTransaction creates an unsigned EIP-2930 transaction. A nil
+// recipient creates a contract.
+func NewAccessListTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := newTransaction(nonce, to, amount, gasLimit, gasPrice, data)
+	tx.data.Type = AccessListTxType
+	tx.data.ChainID = new(big.Int)
+	if chainId != nil {
+		tx.data.ChainID.Set(chainId)
+	}
+	tx.data.AccessList = accessList.copy()
+	return tx
+}
+
+func (tx *Transaction) Type() uint8 {
+	return tx.data.Type
+}
+
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.data.Type != LegacyTxType {
+		return new(big.Int).Set(tx.data.ChainID)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 func (tx *Transaction) Protected() bool {
+	if tx.data.Type != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
@@ -114,18 +149,128 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
+// EncodeRLP encodes legacy transactions as an RLP list and typed ones as an
+// RLP string holding their binary encoding, as they appear in block bodies.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := tx.encodeTyped()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
 }
 
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
+	kind, size, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		var data txdata
+		if err := s.Decode(&data); err != nil {
+			return err
+		}
+		tx.data = data
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		return nil
+	default:
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		if err := tx.decodeTyped(b); err != nil {
+			return err
+		}
+		tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+		return nil
 	}
+}
 
-	return err
+// MarshalBinary returns the canonical encoding of the transaction: the RLP
+// list for legacy transactions, the type byte and payload for typed ones.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.data.Type == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
+	}
+	return tx.encodeTyped()
+}
+
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		var data txdata
+		if err := rlp.DecodeBytes(b, &data); err != nil {
+			return err
+		}
+		tx.data = data
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	}
+	if err := tx.decodeTyped(b); err != nil {
+		return err
+	}
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
+
+// typedPayload returns the consensus payload of a typed transaction.
+func (d *txdata) typedPayload() (interface{}, error) {
+	switch d.Type {
+	case AccessListTxType:
+		return d.accessListRLP(), nil
+	case DynamicFeeTxType:
+		return d.dynamicFeeRLP(), nil
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+}
+
+func (tx *Transaction) encodeTyped() ([]byte, error) {
+	payload, err := tx.data.typedPayload()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.data.Type}, enc...), nil
+}
+
+// checkTxType returns an error unless typ is a known typed transaction kind.
+// Transactions and receipts are both checked here so they accept the same
+// types.
+func checkTxType(typ uint8) error {
+	switch typ {
+	case AccessListTxType, DynamicFeeTxType:
+		return nil
+	default:
+		return ErrTxTypeNotSupported
+	}
+}
+
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) == 0 {
+		return errEmptyTypedTx
+	}
+	if err := checkTxType(b[0]); err != nil {
+		return err
+	}
+	if b[0] == AccessListTxType {
+		var dec accessListTxRLP
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return err
+		}
+		tx.data.setAccessListRLP(&dec)
+		return nil
+	}
+	var dec dynamicFeeTxRLP
+	if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+		return err
+	}
+	tx.data.setDynamicFeeRLP(&dec)
+	return nil
 }
 
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
@@ -141,10 +286,34 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		return err
 	}
 	var V byte
-	if isProtectedV(dec.V) {
+	switch {
+	case dec.Type == AccessListTxType || dec.Type == DynamicFeeTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		if dec.Type == DynamicFeeTxType {
+			if dec.GasTipCap == nil {
+				return errors.New("missing required field 'maxPriorityFeePerGas' for dynamic fee transaction")
+			}
+			// RPC responses report the effective gas price of mined
+			// transactions, the fee cap is carried separately.
+			var caps struct {
+				GasFeeCap *hexutil.Big `json:"maxFeePerGas"`
+			}
+			if err := json.Unmarshal(input, &caps); err != nil {
+				return err
+			}
+			if caps.GasFeeCap != nil {
+				dec.Price = (*big.Int)(caps.GasFeeCap)
+			}
+		}
+		V = byte(dec.V.Uint64())
+	case dec.Type != LegacyTxType:
+		return ErrTxTypeNotSupported
+	case isProtectedV(dec.V):
 		chainID := deriveChainId(dec.V).Uint64()
 		V = byte(dec.V.Uint64() - 35 - 2*chainID)
-	} else {
+	default:
 		V = byte(dec.V.Uint64() - 27)
 	}
 	if !crypto.ValidateSignatureValues(V, dec.R, dec.S, false) {
@@ -161,6 +330,52 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+func (tx *Transaction) AccessList() AccessList { return tx.data.AccessList.copy() }
+
+// GasFeeCap is the most the sender pays per gas. For legacy and access list
+// transactions it is the gas price.
+func (tx *Transaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.data.Price) }
+
+// GasTipCap is the most the sender pays per gas on top of the base fee. For
+// legacy and access list transactions it is the gas price.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.data.Type == DynamicFeeTxType {
+		return new(big.Int).Set(tx.data.GasTipCap)
+	}
+	return new(big.Int).Set(tx.data.Price)
+}
+
+// EffectiveGasTip returns the per gas amount the block producer receives when
+// the transaction is included in a block with the given base fee. The error
+// is ErrGasFeeCapTooLow if the fee cap doesn't cover the base fee.
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) (*big.Int, error) {
+	if baseFee == nil {
+		return tx.GasTipCap(), nil
+	}
+	var err error
+	tip := new(big.Int).Sub(tx.data.Price, baseFee)
+	if tip.Sign() < 0 {
+		err = ErrGasFeeCapTooLow
+	}
+	if gasTipCap := tx.GasTipCap(); tip.Cmp(gasTipCap) > 0 {
+		tip = gasTipCap
+	}
+	return tip, err
+}
+
+// EffectiveGasPrice returns the per gas amount actually paid by the sender
+// in a block with the given base fee.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.data.Price) > 0 {
+		price.Set(tx.data.Price)
+	}
+	return price
+}
+
 func (tx *Transaction) To() *common.Address {
 	if tx.data.Recipient == nil {
 		return nil
@@ -173,7 +388,13 @@ func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.data.Type == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		payload, _ := tx.data.typedPayload()
+		v = prefixedRlpHash(tx.data.Type, payload)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -183,19 +404,24 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
 
-func (tx *Transaction) AsMessage(s Signer) (Message, error) {
+// AsMessage returns the transaction as a core.Message. The gas price of the
+// message is the effective price paid in a block with the given base fee.
+func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 	msg := Message{
 		nonce:      tx.data.AccountNonce,
 		gasLimit:   tx.data.GasLimit,
-		gasPrice:   new(big.Int).Set(tx.data.Price),
+		gasPrice:   tx.EffectiveGasPrice(baseFee),
+		gasFeeCap:  tx.GasFeeCap(),
+		gasTipCap:  tx.GasTipCap(),
 		to:         tx.data.Recipient,
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
+		accessList: tx.data.AccessList,
 		checkNonce: true,
 	}
 
@@ -229,6 +455,9 @@ func (tx *Transaction) String() string {
 	if tx.data.V != nil {
 
 		signer := deriveSigner(tx.data.V)
+		if tx.data.Type != LegacyTxType {
+			signer = NewDynamicFeeSigner(tx.data.ChainID)
+		}
 		if f, err := Sender(signer, tx); err != nil { 
 			from = "[invalid sender: invalid sig]"
 		} else {
@@ -243,9 +472,10 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := tx.MarshalBinary()
 	return fmt.Sprintf(`
 	TX(%x)
+	Type:     %d
 	Contract: %v
 	From:     %s
 	To:       %s
@@ -260,6 +490,7 @@ func (tx *Transaction) String() string {
 	Hex:      %x
 `,
 		tx.Hash(),
+		tx.data.Type,
 		tx.data.Recipient == nil,
 		from,
 		to,
@@ -281,8 +512,10 @@ func (s Transactions) Len() int { return len(s) }
 
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
+// GetRlp returns the canonical encoding of the i'th transaction, which is the
+// value stored in the transaction trie.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -309,21 +542,30 @@ func (s TxByNonce) Len() int           { return len(s) }
 func (s TxByNonce) Less(i, j int) bool { return s[i].data.AccountNonce < s[j].data.AccountNonce }
 func (s TxByNonce) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-type TxByPrice Transactions
+// TxByPrice orders transactions by the tip they pay on top of baseFee, which
+// is the gas price itself when baseFee is nil.
+type TxByPrice struct {
+	txs     Transactions
+	baseFee *big.Int
+}
 
-func (s TxByPrice) Len() int           { return len(s) }
-func (s TxByPrice) Less(i, j int) bool { return s[i].data.Price.Cmp(s[j].data.Price) > 0 }
-func (s TxByPrice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s TxByPrice) Len() int { return len(s.txs) }
+func (s TxByPrice) Less(i, j int) bool {
+	tipi, _ := s.txs[i].EffectiveGasTip(s.baseFee)
+	tipj, _ := s.txs[j].EffectiveGasTip(s.baseFee)
+	return tipi.Cmp(tipj) > 0
+}
+func (s TxByPrice) Swap(i, j int) { s.txs[i], s.txs[j] = s.txs[j], s.txs[i] }
 
 func (s *TxByPrice) Push(x interface{}) {
-	*s = append(*s, x.(*Transaction))
+	s.txs = append(s.txs, x.(*Transaction))
 }
 
 func (s *TxByPrice) Pop() interface{} {
-	old := *s
+	old := s.txs
 	n := len(old)
 	x := old[n-1]
-	*s = old[0 : n-1]
+	s.txs = old[0 : n-1]
 	return x
 }
 
@@ -333,11 +575,13 @@ type TransactionsByPriceAndNonce struct {
 	signer Signer                          
 }
 
-func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
+// NewTransactionsByPriceAndNonce returns the given transactions ordered by
+// the tip they pay in a block with the given base fee, which may be nil.
+func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int) *TransactionsByPriceAndNonce {
 
-	heads := make(TxByPrice, 0, len(txs))
+	heads := TxByPrice{txs: make(Transactions, 0, len(txs)), baseFee: baseFee}
 	for _, accTxs := range txs {
-		heads = append(heads, accTxs[0])
+		heads.txs = append(heads.txs, accTxs[0])
 
 		acc, _ := Sender(signer, accTxs[0])
 		txs[acc] = accTxs[1:]
@@ -352,16 +596,16 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transa
 }
 
 func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
-	if len(t.heads) == 0 {
+	if len(t.heads.txs) == 0 {
 		return nil
 	}
-	return t.heads[0]
+	return t.heads.txs[0]
 }
 
 func (t *TransactionsByPriceAndNonce) Shift() {
-	acc, _ := Sender(t.signer, t.heads[0])
+	acc, _ := Sender(t.signer, t.heads.txs[0])
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		t.heads[0], t.txs[acc] = txs[0], txs[1:]
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
 		heap.Fix(&t.heads, 0)
 	} else {
 		heap.Pop(&t.heads)
@@ -379,11 +623,14 @@ type Message struct {
 	amount     *big.Int
 	gasLimit   uint64
 	gasPrice   *big.Int
+	gasFeeCap  *big.Int
+	gasTipCap  *big.Int
 	data       []byte
+	accessList AccessList
 	checkNonce bool
 }
 
-func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, checkNonce bool) Message {
+func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, checkNonce bool) Message {
 	return Message{
 		from:       from,
 		to:         to,
@@ -391,7 +638,10 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 		amount:     amount,
 		gasLimit:   gasLimit,
 		gasPrice:   gasPrice,
+		gasFeeCap:  gasFeeCap,
+		gasTipCap:  gasTipCap,
 		data:       data,
+		accessList: accessList,
 		checkNonce: checkNonce,
 	}
 }
@@ -399,8 +649,11 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 func (m Message) From() common.Address { return m.from }
 func (m Message) To() *common.Address  { return m.to }
 func (m Message) GasPrice() *big.Int   { return m.gasPrice }
+func (m Message) GasFeeCap() *big.Int  { return m.gasFeeCap }
+func (m Message) GasTipCap() *big.Int  { return m.gasTipCap }
 func (m Message) Value() *big.Int      { return m.amount }
 func (m Message) Gas() uint64          { return m.gasLimit }
 func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
+func (m Message) AccessList() AccessList { return m.accessList }
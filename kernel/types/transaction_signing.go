@@ -24,6 +24,10 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsBaseFee(blockNumber):
+		signer = NewDynamicFeeSigner(config.ChainId)
+	case config.IsAccessList(blockNumber):
+		signer = NewEIP2930Signer(config.ChainId)
 	case config.IsEIP155(blockNumber):
 		signer = NewEIP155Signer(config.ChainId)
 	case config.IsHomestead(blockNumber):
@@ -71,6 +75,123 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+// DynamicFeeSigner accepts dynamic fee transactions in addition to the
+// transaction types of EIP2930Signer.
+type DynamicFeeSigner struct{ EIP2930Signer }
+
+func NewDynamicFeeSigner(chainId *big.Int) DynamicFeeSigner {
+	return DynamicFeeSigner{NewEIP2930Signer(chainId)}
+}
+
+func (s DynamicFeeSigner) Equal(s2 Signer) bool {
+	dynamic, ok := s2.(DynamicFeeSigner)
+	return ok && dynamic.chainId.Cmp(s.chainId) == 0
+}
+
+func (s DynamicFeeSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.Sender(tx)
+	}
+	if tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	V := new(big.Int).Add(tx.data.V, big.NewInt(27))
+	return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+}
+
+func (s DynamicFeeSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.SignatureValues(tx, sig)
+	}
+	if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return R, S, big.NewInt(int64(sig[64])), nil
+}
+
+func (s DynamicFeeSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.Hash(tx)
+	}
+	return prefixedRlpHash(tx.Type(), []interface{}{
+		s.chainId,
+		tx.data.AccountNonce,
+		tx.data.GasTipCap,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.AccessList,
+	})
+}
+
+// EIP2930Signer accepts access list transactions on top of the replay
+// protected legacy transactions of EIP155Signer.
+type EIP2930Signer struct{ EIP155Signer }
+
+func NewEIP2930Signer(chainId *big.Int) EIP2930Signer {
+	return EIP2930Signer{NewEIP155Signer(chainId)}
+}
+
+func (s EIP2930Signer) Equal(s2 Signer) bool {
+	eip2930, ok := s2.(EIP2930Signer)
+	return ok && eip2930.chainId.Cmp(s.chainId) == 0
+}
+
+func (s EIP2930Signer) Sender(tx *Transaction) (common.Address, error) {
+	switch tx.Type() {
+	case LegacyTxType:
+		return s.EIP155Signer.Sender(tx)
+	case AccessListTxType:
+		if tx.data.ChainID.Cmp(s.chainId) != 0 {
+			return common.Address{}, ErrInvalidChainId
+		}
+		V := new(big.Int).Add(tx.data.V, big.NewInt(27))
+		return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+	default:
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+}
+
+func (s EIP2930Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	switch tx.Type() {
+	case LegacyTxType:
+		return s.EIP155Signer.SignatureValues(tx, sig)
+	case AccessListTxType:
+		if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+			return nil, nil, nil, ErrInvalidChainId
+		}
+		R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return R, S, big.NewInt(int64(sig[64])), nil
+	default:
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+}
+
+func (s EIP2930Signer) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != AccessListTxType {
+		return s.EIP155Signer.Hash(tx)
+	}
+	return prefixedRlpHash(tx.Type(), []interface{}{
+		s.chainId,
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.AccessList,
+	})
+}
+
 type EIP155Signer struct {
 	chainId, chainIdMul *big.Int
 }
@@ -93,6 +214,9 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 var big8 = big.NewInt(8)
 
 func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	if !tx.Protected() {
 		return HomesteadSigner{}.Sender(tx)
 	}
@@ -140,6 +264,9 @@ func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v
 }
 
 func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(hs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, true)
 }
 
@@ -172,6 +299,9 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(fs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, false)
 }
 
@@ -93,6 +93,13 @@ func gasReturnDataCopy(gt params.GasTable, evm *EVM, contract *Contract, stack *
 }
 
 func gasSStore(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if evm.chainRules.IsConstantinople && !evm.chainRules.IsPetersburg {
+		return gasSStoreEIP1283(gt, evm, contract, stack, mem, memorySize)
+	}
+	return gasSStoreLegacy(gt, evm, contract, stack, mem, memorySize)
+}
+
+func gasSStoreLegacy(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	var (
 		y, x = stack.Back(1), stack.Back(0)
 		val  = evm.StateDB.GetState(contract.Address(), common.BigToHash(x))
@@ -111,6 +118,89 @@ func gasSStore(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, m
 	}
 }
 
+// gasSStoreEIP1283 implements net gas metering, charging for the change of a
+// slot against its value at the start of the transaction rather than for
+// every write.
+func gasSStoreEIP1283(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	var (
+		y, x    = stack.Back(1), stack.Back(0)
+		slot    = common.BigToHash(x)
+		value   = common.BigToHash(y)
+		current = evm.StateDB.GetState(contract.Address(), slot)
+	)
+	if current == value {
+		return params.NetSstoreNoopGas, nil
+	}
+	original := evm.StateDB.GetCommittedState(contract.Address(), slot)
+	if original == current {
+		if original == (common.Hash{}) {
+			return params.NetSstoreInitGas, nil
+		}
+		if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.NetSstoreClearRefund)
+		}
+		return params.NetSstoreCleanGas, nil
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			evm.StateDB.SubRefund(params.NetSstoreClearRefund)
+		} else if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.NetSstoreClearRefund)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.NetSstoreResetClearRefund)
+		} else {
+			evm.StateDB.AddRefund(params.NetSstoreResetRefund)
+		}
+	}
+	return params.NetSstoreDirtyGas, nil
+}
+
+// gasSStoreEIP2200 is net gas metering as reintroduced by Istanbul, with
+// no-op and dirty writes priced like a read and a minimum of gas left over
+// so that the stipend of a value transfer cannot modify storage.
+func gasSStoreEIP2200(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if contract.Gas <= params.SstoreSentryGasEIP2200 {
+		return 0, ErrSStoreSentry
+	}
+	var (
+		y, x    = stack.Back(1), stack.Back(0)
+		slot    = common.BigToHash(x)
+		value   = common.BigToHash(y)
+		current = evm.StateDB.GetState(contract.Address(), slot)
+	)
+	if current == value {
+		return params.SloadGasEIP2200, nil
+	}
+	original := evm.StateDB.GetCommittedState(contract.Address(), slot)
+	if original == current {
+		if original == (common.Hash{}) {
+			return params.SstoreInitGasEIP2200, nil
+		}
+		if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+		return params.SstoreCleanGasEIP2200, nil
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			evm.StateDB.SubRefund(params.SstoreClearRefundEIP2200)
+		} else if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreInitRefundEIP2200)
+		} else {
+			evm.StateDB.AddRefund(params.SstoreCleanRefundEIP2200)
+		}
+	}
+	return params.SloadGasEIP2200, nil
+}
+
 func makeGasLog(n uint64) gasFunc {
 	return func(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 		requestedSize, overflow := bigUint64(stack.Back(1))
@@ -263,10 +353,36 @@ func gasCreate(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, m
 	return gas, nil
 }
 
+func gasCreate2(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	var overflow bool
+	gas, err := memoryGasCost(mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	if gas, overflow = math.SafeAdd(gas, params.Create2Gas); overflow {
+		return 0, errGasUintOverflow
+	}
+	wordGas, overflow := bigUint64(stack.Back(2))
+	if overflow {
+		return 0, errGasUintOverflow
+	}
+	if wordGas, overflow = math.SafeMul(toWordSize(wordGas), params.Sha3WordGas); overflow {
+		return 0, errGasUintOverflow
+	}
+	if gas, overflow = math.SafeAdd(gas, wordGas); overflow {
+		return 0, errGasUintOverflow
+	}
+	return gas, nil
+}
+
 func gasBalance(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	return gt.Balance, nil
 }
 
+func gasExtCodeHash(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return gt.ExtcodeHash, nil
+}
+
 func gasExtCodeSize(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	return gt.ExtcodeSize, nil
 }
@@ -259,6 +259,44 @@ func opByte(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Sta
 	return nil, nil
 }
 
+func opSHL(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := math.U256(stack.pop()), math.U256(stack.peek())
+	if shift.Cmp(common.Big256) >= 0 {
+		value.SetUint64(0)
+	} else {
+		math.U256(value.Lsh(value, uint(shift.Uint64())))
+	}
+	evm.interpreter.intPool.put(shift)
+	return nil, nil
+}
+
+func opSHR(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := math.U256(stack.pop()), math.U256(stack.peek())
+	if shift.Cmp(common.Big256) >= 0 {
+		value.SetUint64(0)
+	} else {
+		value.Rsh(value, uint(shift.Uint64()))
+	}
+	evm.interpreter.intPool.put(shift)
+	return nil, nil
+}
+
+func opSAR(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := math.U256(stack.pop()), math.S256(stack.pop())
+	if shift.Cmp(common.Big256) >= 0 {
+		if value.Sign() >= 0 {
+			value.SetUint64(0)
+		} else {
+			value.SetInt64(-1)
+		}
+	} else {
+		value.Rsh(value, uint(shift.Uint64()))
+	}
+	stack.push(math.U256(value))
+	evm.interpreter.intPool.put(shift)
+	return nil, nil
+}
+
 func opAddmod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y, z := stack.pop(), stack.pop(), stack.pop()
 	if z.Cmp(bigZero) > 0 {
@@ -417,6 +455,19 @@ func opExtCodeCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory, sta
 	return nil, nil
 }
 
+// opExtCodeHash pushes the code hash of an account, or zero if the account
+// does not exist or is empty.
+func opExtCodeHash(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	slot := stack.peek()
+	addr := common.BigToAddress(slot)
+	if evm.StateDB.Empty(addr) {
+		slot.SetUint64(0)
+	} else {
+		slot.SetBytes(evm.StateDB.GetCodeHash(addr).Bytes())
+	}
+	return nil, nil
+}
+
 func opGasprice(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	stack.push(evm.interpreter.intPool.get().Set(evm.GasPrice))
 	return nil, nil
@@ -446,6 +497,11 @@ func opTimestamp(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack
 	return nil, nil
 }
 
+func opTimestampMS(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	stack.push(math.U256(new(big.Int).Set(evm.TimeMS)))
+	return nil, nil
+}
+
 func opNumber(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	stack.push(math.U256(new(big.Int).Set(evm.BlockNumber)))
 	return nil, nil
@@ -461,6 +517,16 @@ func opGasLimit(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack
 	return nil, nil
 }
 
+func opChainID(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	stack.push(evm.interpreter.intPool.get().Set(evm.ChainConfig().ChainId))
+	return nil, nil
+}
+
+func opSelfBalance(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	stack.push(evm.interpreter.intPool.get().Set(evm.StateDB.GetBalance(contract.Address())))
+	return nil, nil
+}
+
 func opPop(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	evm.interpreter.intPool.put(stack.pop())
 	return nil, nil
@@ -584,6 +650,33 @@ func opCreate(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 	return nil, nil
 }
 
+func opCreate2(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	var (
+		endowment    = stack.pop()
+		offset, size = stack.pop(), stack.pop()
+		salt         = stack.pop()
+		input        = memory.Get(offset.Int64(), size.Int64())
+		gas          = contract.Gas
+	)
+	gas -= gas / 64
+
+	contract.UseGas(gas)
+	res, addr, returnGas, suberr := evm.Create2(contract, input, gas, endowment, salt)
+
+	if suberr != nil {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(addr.Big())
+	}
+	contract.Gas += returnGas
+	evm.interpreter.intPool.put(endowment, offset, size, salt)
+
+	if suberr == errExecutionReverted {
+		return res, nil
+	}
+	return nil, nil
+}
+
 func opCall(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 
 	evm.interpreter.intPool.put(stack.pop())
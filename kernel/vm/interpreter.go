@@ -25,6 +25,10 @@ type Config struct {
 
 	EnablePreimageRecording bool
 
+	// NoBaseFee lets messages without fee fields skip the base fee check,
+	// for calls that are not part of a block.
+	NoBaseFee bool
+
 	JumpTable [256]operation
 }
 
@@ -42,6 +46,10 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 
 	if !cfg.JumpTable[STOP].valid {
 		switch {
+		case evm.ChainConfig().IsIstanbul(evm.BlockNumber):
+			cfg.JumpTable = istanbulInstructionSet
+		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
+			cfg.JumpTable = constantinopleInstructionSet
 		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
 			cfg.JumpTable = byzantiumInstructionSet
 		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
@@ -49,6 +57,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		default:
 			cfg.JumpTable = frontierInstructionSet
 		}
+		if evm.ChainConfig().IsMillisecondTime(evm.BlockNumber) {
+			cfg.JumpTable = enableMillisecondTime(cfg.JumpTable)
+		}
+		if evm.ChainConfig().IsAccessList(evm.BlockNumber) {
+			cfg.JumpTable = enableAccessList(cfg.JumpTable)
+		}
 	}
 
 	return &Interpreter{
@@ -39,8 +39,101 @@ var (
 	frontierInstructionSet  = NewFrontierInstructionSet()
 	homesteadInstructionSet = NewHomesteadInstructionSet()
 	byzantiumInstructionSet = NewByzantiumInstructionSet()
+
+	constantinopleInstructionSet = NewConstantinopleInstructionSet()
+	istanbulInstructionSet       = NewIstanbulInstructionSet()
 )
 
+func enableMillisecondTime(instructionSet [256]operation) [256]operation {
+	instructionSet[TIMESTAMPMS] = operation{
+		execute:       opTimestampMS,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	return instructionSet
+}
+
+// enableAccessList switches the state accessing operations to warm/cold
+// pricing based on the access list of the transaction.
+func enableAccessList(instructionSet [256]operation) [256]operation {
+	instructionSet[SLOAD].gasCost = gasSLoadAccessList
+	instructionSet[SSTORE].gasCost = gasSStoreAccessList
+	instructionSet[BALANCE].gasCost = gasBalanceAccessList
+	instructionSet[EXTCODESIZE].gasCost = gasExtCodeSizeAccessList
+	instructionSet[EXTCODECOPY].gasCost = gasExtCodeCopyAccessList
+	if instructionSet[EXTCODEHASH].valid {
+		instructionSet[EXTCODEHASH].gasCost = gasExtCodeHashAccessList
+	}
+	instructionSet[SELFDESTRUCT].gasCost = gasSuicideAccessList
+	for _, op := range []OpCode{CALL, CALLCODE, DELEGATECALL, STATICCALL} {
+		if instructionSet[op].valid {
+			instructionSet[op].gasCost = makeCallAccessList(instructionSet[op].gasCost)
+		}
+	}
+	return instructionSet
+}
+
+// NewIstanbulInstructionSet returns the Constantinople instructions with
+// CHAINID, SELFBALANCE and EIP-2200 net gas metering for SSTORE.
+func NewIstanbulInstructionSet() [256]operation {
+	instructionSet := NewConstantinopleInstructionSet()
+	instructionSet[CHAINID] = operation{
+		execute:       opChainID,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[SELFBALANCE] = operation{
+		execute:       opSelfBalance,
+		gasCost:       constGasFunc(params.SelfBalanceGas),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2200
+	return instructionSet
+}
+
+// NewConstantinopleInstructionSet returns the Byzantium instructions with the
+// bitwise shifts, EXTCODEHASH and CREATE2.
+func NewConstantinopleInstructionSet() [256]operation {
+	instructionSet := NewByzantiumInstructionSet()
+	instructionSet[SHL] = operation{
+		execute:       opSHL,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+	instructionSet[SHR] = operation{
+		execute:       opSHR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+	instructionSet[SAR] = operation{
+		execute:       opSAR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+	instructionSet[EXTCODEHASH] = operation{
+		execute:       opExtCodeHash,
+		gasCost:       gasExtCodeHash,
+		validateStack: makeStackFunc(1, 1),
+		valid:         true,
+	}
+	instructionSet[CREATE2] = operation{
+		execute:       opCreate2,
+		gasCost:       gasCreate2,
+		validateStack: makeStackFunc(4, 1),
+		memorySize:    memoryCreate2,
+		valid:         true,
+		writes:        true,
+		returns:       true,
+	}
+	return instructionSet
+}
+
 func NewByzantiumInstructionSet() [256]operation {
 
 	instructionSet := NewHomesteadInstructionSet()
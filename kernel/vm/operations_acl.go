@@ -0,0 +1,137 @@
+package vm
+
+import (
+	"github.com/epvchain/go-epvchain/public"
+	"github.com/epvchain/go-epvchain/content"
+)
+
+// accountAccessCost charges for touching an address, warming it up for the
+// rest of the transaction on first access.
+func accountAccessCost(evm *EVM, addr common.Address) uint64 {
+	if evm.StateDB.AddressInAccessList(addr) {
+		return params.WarmStorageReadCost
+	}
+	evm.StateDB.AddAddressToAccessList(addr)
+	return params.ColdAccountAccessCost
+}
+
+func gasSLoadAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	slot := common.BigToHash(stack.Back(0))
+	if _, slotOk := evm.StateDB.SlotInAccessList(contract.Address(), slot); slotOk {
+		return params.WarmStorageReadCost, nil
+	}
+	evm.StateDB.AddSlotToAccessList(contract.Address(), slot)
+	return params.ColdSloadCost, nil
+}
+
+// gasSStoreAccessList charges the cold slot surcharge on top of the legacy
+// SSTORE cost, whose reset price is lowered by the cold read it now includes.
+// Once Istanbul is active the surcharge applies to net gas metering instead.
+func gasSStoreAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if evm.chainRules.IsIstanbul {
+		return gasSStoreNetAccessList(gt, evm, contract, stack, mem, memorySize)
+	}
+	var (
+		slot = common.BigToHash(stack.Back(0))
+		cost uint64
+	)
+	if _, slotOk := evm.StateDB.SlotInAccessList(contract.Address(), slot); !slotOk {
+		evm.StateDB.AddSlotToAccessList(contract.Address(), slot)
+		cost = params.ColdSloadCost
+	}
+	gas, err := gasSStoreLegacy(gt, evm, contract, stack, mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	if gas != params.SstoreSetGas {
+		gas -= params.ColdSloadCost
+	}
+	return gas + cost, nil
+}
+
+// gasSStoreNetAccessList is EIP-2200 net gas metering with the slot read
+// priced as a warm or cold access.
+func gasSStoreNetAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if contract.Gas <= params.SstoreSentryGasEIP2200 {
+		return 0, ErrSStoreSentry
+	}
+	var (
+		y, x  = stack.Back(1), stack.Back(0)
+		slot  = common.BigToHash(x)
+		value = common.BigToHash(y)
+		cost  uint64
+	)
+	if _, slotOk := evm.StateDB.SlotInAccessList(contract.Address(), slot); !slotOk {
+		evm.StateDB.AddSlotToAccessList(contract.Address(), slot)
+		cost = params.ColdSloadCost
+	}
+	current := evm.StateDB.GetState(contract.Address(), slot)
+	if current == value {
+		return cost + params.WarmStorageReadCost, nil
+	}
+	original := evm.StateDB.GetCommittedState(contract.Address(), slot)
+	if original == current {
+		if original == (common.Hash{}) {
+			return cost + params.SstoreInitGasEIP2200, nil
+		}
+		if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+		return cost + params.SstoreCleanGasEIP2200 - params.ColdSloadCost, nil
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			evm.StateDB.SubRefund(params.SstoreClearRefundEIP2200)
+		} else if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreInitGasEIP2200 - params.WarmStorageReadCost)
+		} else {
+			evm.StateDB.AddRefund(params.SstoreCleanGasEIP2200 - params.ColdSloadCost - params.WarmStorageReadCost)
+		}
+	}
+	return cost + params.WarmStorageReadCost, nil
+}
+
+func gasBalanceAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return accountAccessCost(evm, common.BigToAddress(stack.Back(0))), nil
+}
+
+func gasExtCodeSizeAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return accountAccessCost(evm, common.BigToAddress(stack.Back(0))), nil
+}
+
+func gasExtCodeHashAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return accountAccessCost(evm, common.BigToAddress(stack.Back(0))), nil
+}
+
+func gasExtCodeCopyAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	gt.ExtcodeCopy = accountAccessCost(evm, common.BigToAddress(stack.Back(0)))
+	return gasExtCodeCopy(gt, evm, contract, stack, mem, memorySize)
+}
+
+// makeCallAccessList replaces the flat call cost of the legacy gas function
+// with the warm or cold access cost of the callee.
+func makeCallAccessList(oldCalculator gasFunc) gasFunc {
+	return func(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		gt.Calls = accountAccessCost(evm, common.BigToAddress(stack.Back(1)))
+		return oldCalculator(gt, evm, contract, stack, mem, memorySize)
+	}
+}
+
+func gasSuicideAccessList(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	address := common.BigToAddress(stack.Back(0))
+
+	gas, err := gasSuicide(gt, evm, contract, stack, mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	if !evm.StateDB.AddressInAccessList(address) {
+		evm.StateDB.AddAddressToAccessList(address)
+		gas += params.ColdAccountAccessCost
+	}
+	return gas, nil
+}
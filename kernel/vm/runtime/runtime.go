@@ -21,6 +21,7 @@ type Config struct {
 	Coinbase    common.Address
 	BlockNumber *big.Int
 	Time        *big.Int
+	TimeMS      *big.Int
 	GasLimit    uint64
 	GasPrice    *big.Int
 	Value       *big.Int
@@ -51,6 +52,9 @@ func setDefaults(cfg *Config) {
 	if cfg.Time == nil {
 		cfg.Time = big.NewInt(time.Now().Unix())
 	}
+	if cfg.TimeMS == nil {
+		cfg.TimeMS = new(big.Int).Mul(cfg.Time, big.NewInt(1000))
+	}
 	if cfg.GasLimit == 0 {
 		cfg.GasLimit = math.MaxUint64
 	}
@@ -16,7 +16,9 @@ import (
 	"github.com/epvchain/go-epvchain/public/hexutil"
 	"github.com/epvchain/go-epvchain/public/math"
 	"github.com/epvchain/go-epvchain/agreement/epvhash"
+	"github.com/epvchain/go-epvchain/agreement/misc"
 	"github.com/epvchain/go-epvchain/kernel"
+	"github.com/epvchain/go-epvchain/kernel/state"
 	"github.com/epvchain/go-epvchain/kernel/types"
 	"github.com/epvchain/go-epvchain/kernel/vm"
 	"github.com/epvchain/go-epvchain/code"
@@ -45,6 +47,42 @@ func (s *PublicEPVchainAPI) GasPrice(ctx context.Context) (*big.Int, error) {
 	return s.b.SuggestPrice(ctx)
 }
 
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the base fee and gas usage of a range of blocks ending
+// with lastBlock, and the tips paid at the requested percentiles of gas used.
+func (s *PublicEPVchainAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	oldest, reward, baseFee, gasUsed, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	results := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: gasUsed,
+	}
+	if reward != nil {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, w := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				results.Reward[i][j] = (*hexutil.Big)(v)
+			}
+		}
+	}
+	if baseFee != nil {
+		results.BaseFee = make([]*hexutil.Big, len(baseFee))
+		for i, v := range baseFee {
+			results.BaseFee[i] = (*hexutil.Big)(v)
+		}
+	}
+	return results, nil
+}
+
 func (s *PublicEPVchainAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
 }
@@ -286,7 +324,7 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 	tx := args.toTransaction()
 
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) || tx.Type() != types.LegacyTxType {
 		chainID = config.ChainId
 	}
 	return wallet.SignTxWithPassphrase(account, passwd, tx, chainID)
@@ -320,7 +358,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -478,6 +516,73 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// AccountResult is the EIP-1186 proof of an account and some of its storage
+// slots, all proven against the state root of a block.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a single storage slot against the storage
+// root of its account.
+type StorageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// GetProof returns the Merkle proofs of the account and the given storage
+// slots in the state of blockNr.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	storageHash, codeHash := types.EmptyRootHash, crypto.Keccak256Hash(nil)
+	if storageTrie := state.StorageTrie(address); storageTrie != nil {
+		storageHash = storageTrie.Hash()
+		codeHash = state.GetCodeHash(address)
+	}
+
+	storageProof := make([]StorageResult, len(storageKeys))
+	for i, key := range storageKeys {
+		proof, err := state.GetStorageProof(address, common.HexToHash(key))
+		if err != nil {
+			return nil, err
+		}
+		value := state.GetState(address, common.HexToHash(key)).Big()
+		storageProof[i] = StorageResult{key, (*hexutil.Big)(value), toHexSlice(proof)}
+	}
+
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+// toHexSlice encodes every proof node as a hex string.
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
+}
+
 type CallArgs struct {
 	From     common.Address  `json:"from"`
 	To       *common.Address `json:"to"`
@@ -485,19 +590,90 @@ type CallArgs struct {
 	GasPrice hexutil.Big     `json:"gasPrice"`
 	Value    hexutil.Big     `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
+
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+// OverrideAccount holds the fields of an account to replace before executing
+// a call. State replaces the whole storage, StateDiff only the given slots.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   *hexutil.Big                 `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
 
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, 0, false, err
+// StateOverride is the set of accounts to override before executing a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply writes the overrides into the given state.
+func (diff *StateOverride) Apply(state *state.StateDB) error {
+	if diff == nil {
+		return nil
 	}
+	for addr, account := range *diff {
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			state.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.State != nil {
+			state.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				state.SetState(addr, key, value)
+			}
+		}
+	}
+	// Overridden values count as the committed state of the call.
+	state.Finalise(false)
+	return nil
+}
 
+// BlockOverrides holds the block context fields to replace before executing
+// a call. Time is given in seconds.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Uint64 `json:"time"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// Apply writes the overrides into the given block context.
+func (diff *BlockOverrides) Apply(blockCtx *vm.Context) {
+	if diff == nil {
+		return
+	}
+	if diff.Number != nil {
+		blockCtx.BlockNumber = new(big.Int).Set(diff.Number.ToInt())
+	}
+	if diff.Time != nil {
+		blockCtx.Time = new(big.Int).SetUint64(uint64(*diff.Time))
+		blockCtx.TimeMS = new(big.Int).SetUint64(uint64(*diff.Time) * 1000)
+	}
+	if diff.Coinbase != nil {
+		blockCtx.Coinbase = *diff.Coinbase
+	}
+}
+
+// ToMessage converts the call arguments into a message executed on top of
+// header. Without a sender the first local account is used. Calls without
+// any fee fields after the base fee fork are priced at zero.
+func (args *CallArgs) ToMessage(am *accounts.Manager, header *types.Header) types.Message {
 	addr := args.From
 	if addr == (common.Address{}) {
-		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+		if wallets := am.Wallets(); len(wallets) > 0 {
 			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
 				addr = accounts[0].Address
 			}
@@ -508,11 +684,45 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	if gas == 0 {
 		gas = 50000000
 	}
-	if gasPrice.Sign() == 0 {
-		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	gasFeeCap, gasTipCap := gasPrice, gasPrice
+	switch {
+	case header.BaseFee == nil:
+		if gasPrice.Sign() == 0 {
+			gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+		}
+		gasFeeCap, gasTipCap = gasPrice, gasPrice
+
+	case gasPrice.Sign() == 0:
+		// Calls without any fee fields are executed free of charge.
+		gasFeeCap, gasTipCap = new(big.Int), new(big.Int)
+		if args.MaxFeePerGas != nil {
+			gasFeeCap = args.MaxFeePerGas.ToInt()
+		}
+		if args.MaxPriorityFeePerGas != nil {
+			gasTipCap = args.MaxPriorityFeePerGas.ToInt()
+		}
+		gasPrice = new(big.Int)
+		if gasFeeCap.Sign() > 0 {
+			gasPrice = math.BigMin(new(big.Int).Add(gasTipCap, header.BaseFee), gasFeeCap)
+		}
 	}
 
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	return types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, gasFeeCap, gasTipCap, args.Data, accessList, false)
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides, vmCfg vm.Config) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
+	msg := args.ToMessage(s.b.AccountManager(), header)
+	vmCfg.NoBaseFee = true
 
 	var cancel context.CancelFunc
 	if vmCfg.DisableGasMetering {
@@ -523,10 +733,13 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 
 	defer func() { cancel() }()
 
-	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
+	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg, blockOverrides)
 	if err != nil {
 		return nil, 0, false, err
 	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, 0, false, err
+	}
 
 	go func() {
 		<-ctx.Done()
@@ -541,12 +754,17 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	return res, gas, failed, err
 }
 
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{DisableGasMetering: true})
+// Call executes the given call on top of the state of blockNr, optionally
+// with some accounts and block context fields overridden.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Bytes, error) {
+	result, _, _, err := s.doCall(ctx, args, blockNr, overrides, blockOverrides, vm.Config{DisableGasMetering: true})
 	return (hexutil.Bytes)(result), err
 }
 
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
+// EstimateGas returns the lowest gas limit the call succeeds with on top of
+// the pending state, optionally with some accounts and block context fields
+// overridden.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Uint64, error) {
 
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -568,7 +786,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	executable := func(gas uint64) bool {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{})
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, overrides, blockOverrides, vm.Config{})
 		if err != nil || failed {
 			return false
 		}
@@ -669,6 +887,9 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
 	}
+	if head.BaseFee != nil {
+		fields["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -717,12 +938,22 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+
+	Type       hexutil.Uint64    `json:"type"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+
+	GasFeeCap *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	GasTipCap *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
-func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
+// newRPCTransaction returns a transaction that will serialize to the RPC
+// representation. For mined dynamic fee transactions the gas price is the
+// effective price paid given the base fee of the including block.
+func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.NewDynamicFeeSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 	v, r, s := tx.RawSignatureValues()
@@ -739,6 +970,19 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		V:        (*hexutil.Big)(v),
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
+		Type:     hexutil.Uint64(tx.Type()),
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+		result.AccessList = &accessList
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		result.GasFeeCap = (*hexutil.Big)(tx.GasFeeCap())
+		result.GasTipCap = (*hexutil.Big)(tx.GasTipCap())
+		if blockHash != (common.Hash{}) {
+			result.GasPrice = (*hexutil.Big)(tx.EffectiveGasPrice(baseFee))
+		}
 	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
@@ -749,7 +993,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 }
 
 func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
-	return newRPCTransaction(tx, common.Hash{}, 0, 0)
+	return newRPCTransaction(tx, common.Hash{}, 0, 0, nil)
 }
 
 func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransaction {
@@ -757,7 +1001,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransacti
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index)
+	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index, b.BaseFee())
 }
 
 func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.Bytes {
@@ -765,7 +1009,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -843,7 +1087,11 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) *RPCTransaction {
 
 	if tx, blockHash, blockNumber, index := core.GetTransaction(s.b.ChainDb(), hash); tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index)
+		var baseFee *big.Int
+		if header := core.GetHeader(s.b.ChainDb(), blockHash, blockNumber); header != nil {
+			baseFee = header.BaseFee
+		}
+		return newRPCTransaction(tx, blockHash, blockNumber, index, baseFee)
 	}
 
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
@@ -863,7 +1111,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 		}
 	}
 
-	return rlp.EncodeToBytes(tx)
+	return tx.MarshalBinary()
 }
 
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[string]interface{}, error) {
@@ -878,10 +1126,14 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[
 
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.NewDynamicFeeSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
+	var baseFee *big.Int
+	if header := core.GetHeader(s.b.ChainDb(), blockHash, blockNumber); header != nil {
+		baseFee = header.BaseFee
+	}
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
@@ -894,6 +1146,8 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
+		"type":              hexutil.Uint(tx.Type()),
+		"effectiveGasPrice": (*hexutil.Big)(tx.EffectiveGasPrice(baseFee)),
 	}
 
 	if len(receipt.PostState) > 0 {
@@ -921,7 +1175,7 @@ func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transacti
 	}
 
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) || tx.Type() != types.LegacyTxType {
 		chainID = config.ChainId
 	}
 	return wallet.SignTx(account, tx, chainID)
@@ -937,6 +1191,18 @@ type SendTxArgs struct {
 
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// An access list turns the request into an EIP-2930 transaction.
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	// Either fee cap turns the request into a dynamic fee transaction.
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
+}
+
+func (args *SendTxArgs) isDynamicFee() bool {
+	return args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil
 }
 
 func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
@@ -944,7 +1210,11 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = new(hexutil.Uint64)
 		*(*uint64)(args.Gas) = 90000
 	}
-	if args.GasPrice == nil {
+	if args.isDynamicFee() {
+		if err := args.setFeeDefaults(ctx, b); err != nil {
+			return err
+		}
+	} else if args.GasPrice == nil {
 		price, err := b.SuggestPrice(ctx)
 		if err != nil {
 			return err
@@ -964,6 +1234,49 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
 		return errors.New(`Both "data" and "input" are set and not equal. Please use "input" to pass transaction call data.`)
 	}
+	if args.AccessList != nil || args.isDynamicFee() {
+		config := b.ChainConfig()
+		if !config.IsAccessList(new(big.Int).Add(b.CurrentBlock().Number(), big.NewInt(1))) {
+			return types.ErrTxTypeNotSupported
+		}
+		if args.ChainID == nil {
+			args.ChainID = (*hexutil.Big)(config.ChainId)
+		} else if args.ChainID.ToInt().Cmp(config.ChainId) != 0 {
+			return fmt.Errorf("chainId does not match node's (have=%v, want=%v)", args.ChainID.ToInt(), config.ChainId)
+		}
+	}
+	return nil
+}
+
+// setFeeDefaults fills in the fee caps of a dynamic fee transaction. The tip
+// defaults to the suggested price above the current base fee and the fee cap
+// leaves room for the base fee to double.
+func (args *SendTxArgs) setFeeDefaults(ctx context.Context, b Backend) error {
+	if args.GasPrice != nil {
+		return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	config, head := b.ChainConfig(), b.CurrentBlock().Header()
+	if !config.IsBaseFee(new(big.Int).Add(head.Number, big.NewInt(1))) {
+		return types.ErrTxTypeNotSupported
+	}
+	baseFee := misc.CalcBaseFee(config, head)
+	if args.MaxPriorityFeePerGas == nil {
+		tip, err := b.SuggestPrice(ctx)
+		if err != nil {
+			return err
+		}
+		if head.BaseFee != nil {
+			tip = math.BigMax(tip.Sub(tip, head.BaseFee), new(big.Int))
+		}
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tip)
+	}
+	if args.MaxFeePerGas == nil {
+		feeCap := new(big.Int).Add(args.MaxPriorityFeePerGas.ToInt(), new(big.Int).Mul(baseFee, big.NewInt(2)))
+		args.MaxFeePerGas = (*hexutil.Big)(feeCap)
+	}
+	if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+		return fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas.ToInt(), args.MaxPriorityFeePerGas.ToInt())
+	}
 	return nil
 }
 
@@ -974,6 +1287,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Input != nil {
 		input = *args.Input
 	}
+	if args.isDynamicFee() {
+		var accessList types.AccessList
+		if args.AccessList != nil {
+			accessList = *args.AccessList
+		}
+		return types.NewDynamicFeeTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.MaxPriorityFeePerGas), (*big.Int)(args.MaxFeePerGas), input, accessList)
+	}
+	if args.AccessList != nil {
+		return types.NewAccessListTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input, *args.AccessList)
+	}
 	if args.To == nil {
 		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 	}
@@ -1020,7 +1343,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	tx := args.toTransaction()
 
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) || tx.Type() != types.LegacyTxType {
 		chainID = config.ChainId
 	}
 	signed, err := wallet.SignTx(account, tx, chainID)
@@ -1032,7 +1355,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 
 func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 	return submitTransaction(ctx, s.b, tx)
@@ -1076,7 +1399,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1093,7 +1416,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	for _, tx := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if tx.Protected() {
-			signer = types.NewEIP155Signer(tx.ChainId())
+			signer = types.NewDynamicFeeSigner(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, err := s.b.AccountManager().Find(accounts.Account{Address: from}); err == nil {
@@ -1119,14 +1442,18 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	for _, p := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if p.Protected() {
-			signer = types.NewEIP155Signer(p.ChainId())
+			signer = types.NewDynamicFeeSigner(p.ChainId())
 		}
 		wantSigHash := signer.Hash(matchTx)
 
 		if pFrom, err := types.Sender(signer, p); err == nil && pFrom == sendArgs.From && signer.Hash(p) == wantSigHash {
 
 			if gasPrice != nil {
-				sendArgs.GasPrice = gasPrice
+				if sendArgs.isDynamicFee() {
+					sendArgs.MaxFeePerGas = gasPrice
+				} else {
+					sendArgs.GasPrice = gasPrice
+				}
 			}
 			if gasLimit != nil {
 				sendArgs.Gas = gasLimit
@@ -83,6 +83,28 @@ web3._extend({
 			call: 'dpos_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getInturnSigner',
+			call: 'dpos_getInturnSigner',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getEvidence',
+			call: 'dpos_getEvidence',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getSignerStats',
+			call: 'dpos_getSignerStats',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getCandidates',
+			call: 'dpos_getCandidates',
+			params: 1,
+			inputFormatter: [null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -326,6 +348,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCall',
+			call: 'debug_traceCall',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -400,6 +428,18 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'epv_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.toHex, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'epv_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
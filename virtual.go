@@ -54,6 +54,11 @@ type CallMsg struct {
 	GasPrice *big.Int
 	Value    *big.Int
 	Data     []byte
+
+	AccessList types.AccessList
+
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
 }
 
 type ChainReader interface {
@@ -82,6 +87,15 @@ type GasPricer interface {
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 }
 
+// FeeHistory is the base fee and gas usage of a range of blocks, along with
+// the tips paid at the requested percentiles of gas used in each block.
+type FeeHistory struct {
+	OldestBlock  *big.Int
+	Reward       [][]*big.Int
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+}
+
 type PendingStateEventer interface {
 	SubscribePendingTransactions(ctx context.Context, ch chan<- *types.Transaction) (Subscription, error)
 }
@@ -230,12 +230,12 @@ func (self *worker) update() {
 				self.currentMu.Lock()
 				acc, _ := types.Sender(self.current.signer, ev.Tx)
 				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
-				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
+				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs, self.current.header.BaseFee)
 
 				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
 				self.currentMu.Unlock()
 			} else {
-				if self.config.DPos != nil && self.config.DPos.Period == 0 {
+				if self.config.DPos != nil && self.config.DPos.At(self.chain.CurrentBlock().NumberU64()+1).Period == 0 {
 					self.commitNewWork()
 				}
 			}
@@ -317,7 +317,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	}
 	work := &Work{
 		config:    self.config,
-		signer:    types.NewEIP155Signer(self.config.ChainId),
+		signer:    types.NewDynamicFeeSigner(self.config.ChainId),
 		state:     state,
 		ancestors: set.New(),
 		family:    set.New(),
@@ -368,6 +368,9 @@ func (self *worker) commitNewWork() {
 		Extra:      self.extra,
 		TimeMS:       big.NewInt(tstamp),
 	}
+	if self.config.IsBaseFee(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(self.config, parent.Header())
+	}
 	if atomic.LoadInt32(&self.mining) == 1 {
 		header.Coinbase = self.coinbase
 	}
@@ -399,7 +402,7 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
+	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending, header.BaseFee)
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 
 	var (
@@ -470,6 +473,12 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			txs.Pop()
 			continue
 		}
+		if baseFee := env.header.BaseFee; baseFee != nil && tx.GasFeeCap().Cmp(baseFee) < 0 {
+			log.Trace("Skipping account with fee cap below base fee", "sender", from, "feecap", tx.GasFeeCap(), "basefee", baseFee)
+
+			txs.Pop()
+			continue
+		}
 		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
 
 		err, logs := env.commitTransaction(tx, bc, coinbase, gp)